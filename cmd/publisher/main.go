@@ -46,6 +46,40 @@ func printOrder(order *entity.Order) {
 	fmt.Println("Order: ", string(orderBytes))
 }
 
+var (
+	currencies = []entity.Currency{"RUB", "USD", "EUR", "KZT", "BYN"}
+	locales    = []string{"ru", "en", "kk", "be"}
+)
+
+// normalizeOrder turns random faker data into an order that passes entity.Order validation.
+func normalizeOrder(order *entity.Order) {
+	const (
+		maxPrice = 100000
+		maxSale  = 100
+		maxCost  = 1000
+	)
+
+	currency := currencies[rand.Intn(len(currencies))] // nolint
+	order.Locale = locales[rand.Intn(len(locales))]    // nolint
+	order.Payment.Currency = currency
+	order.Payment.PaymentDt = int(order.DateCreated.Unix())
+	order.Payment.DeliveryCost = entity.NewMoney(rand.Int63n(maxCost), currency) // nolint
+	order.Payment.CustomFee = entity.NewMoney(rand.Int63n(maxCost), currency)    // nolint
+
+	goodsTotal := entity.NewMoney(0, currency)
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.TrackNumber = order.TrackNumber
+		item.Price = entity.NewMoney(rand.Int63n(maxPrice), currency) // nolint
+		item.Sale = rand.Intn(maxSale)                                // nolint
+		item.TotalPrice = item.Price.ApplySale(item.Sale)
+		goodsTotal, _ = goodsTotal.Add(item.TotalPrice)
+	}
+	order.Payment.GoodsTotal = goodsTotal
+	order.Payment.Amount, _ = goodsTotal.Add(order.Payment.DeliveryCost)
+	order.Payment.Amount, _ = order.Payment.Amount.Add(order.Payment.CustomFee)
+}
+
 func publishOrder(channel string, conn stan.Conn, eChan chan<- error) {
 	rand.Seed(time.Now().Unix())
 	for {
@@ -54,10 +88,7 @@ func publishOrder(channel string, conn stan.Conn, eChan chan<- error) {
 			eChan <- err
 		}
 
-		order.Payment.Transaction = order.OrderUID
-		for i := range order.Items {
-			order.Items[i].TrackNumber = order.TrackNumber
-		}
+		normalizeOrder(order)
 
 		data, err := json.Marshal(order)
 		if err != nil {
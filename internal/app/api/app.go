@@ -2,68 +2,232 @@ package api
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	goredis "github.com/go-redis/redis/v8"
 	"github.com/maypok86/wb-l0/internal/cache"
 	"github.com/maypok86/wb-l0/internal/config"
+	"github.com/maypok86/wb-l0/internal/entity"
 	"github.com/maypok86/wb-l0/internal/repository"
 	"github.com/maypok86/wb-l0/internal/transport/http"
+	v1 "github.com/maypok86/wb-l0/internal/transport/http/v1"
 	"github.com/maypok86/wb-l0/internal/transport/stan"
 	"github.com/maypok86/wb-l0/internal/usecase"
 	"github.com/maypok86/wb-l0/pkg/httpserver"
 	"github.com/maypok86/wb-l0/pkg/logger"
 	"github.com/maypok86/wb-l0/pkg/nats"
 	"github.com/maypok86/wb-l0/pkg/postgres"
+	"github.com/maypok86/wb-l0/pkg/redis"
 )
 
+// StartMode tells when the app subscribes to STAN.
+type StartMode string
+
+const (
+	// StartAfterWarmup subscribes once the cache warm-up is over, so redelivered orders hit a warm cache.
+	StartAfterWarmup StartMode = "after-warmup"
+	// StartImmediately subscribes on startup concurrently with the cache warm-up.
+	StartImmediately StartMode = "immediate"
+)
+
+func parseStartMode(mode string) (StartMode, error) {
+	switch StartMode(mode) {
+	case StartAfterWarmup, StartImmediately:
+		return StartMode(mode), nil
+	default:
+		return "", fmt.Errorf("unknown stan start mode %q", mode)
+	}
+}
+
+// newOrderCache builds the cache backend chosen by the config. The memory cache is set for the memory
+// and tiered backends, the redis client for the redis and tiered ones.
+func newOrderCache(
+	ctx context.Context,
+	cfg *config.Config,
+) (usecase.OrderCache, *cache.MemoryCache, *goredis.Client, error) {
+	switch cfg.Cache.Backend {
+	case "memory":
+		memoryCache, err := newMemoryCache(cfg, cfg.Cache.MaxEntries, cfg.Cache.MaxBytes)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return memoryCache, memoryCache, nil, nil
+	case "redis":
+		redisCache, redisClient, err := newRedisCache(ctx, cfg)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return redisCache, nil, redisClient, nil
+	case "tiered":
+		memoryCache, err := newMemoryCache(cfg, cfg.Cache.L1MaxEntries, cfg.Cache.MaxBytes)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		redisCache, redisClient, err := newRedisCache(ctx, cfg)
+		if err != nil {
+			memoryCache.Close()
+			return nil, nil, nil, err
+		}
+		tieredCache, err := cache.NewTieredCache(memoryCache, redisCache, cfg.Cache.L1TTL)
+		if err != nil {
+			memoryCache.Close()
+			_ = redisClient.Close()
+			return nil, nil, nil, fmt.Errorf("can not create tiered cache: %w", err)
+		}
+		return tieredCache, memoryCache, redisClient, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown cache backend %q", cfg.Cache.Backend)
+	}
+}
+
+func newMemoryCache(cfg *config.Config, maxEntries int, maxBytes int64) (*cache.MemoryCache, error) {
+	cachePolicy, err := cache.ParsePolicy(cfg.Cache.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse cache policy: %w", err)
+	}
+	cacheEvictions := expvar.NewMap("cache_evictions")
+	return cache.NewMemoryCache(
+		cache.MaxEntries(maxEntries),
+		cache.MaxBytes(maxBytes),
+		cache.EvictionPolicy(cachePolicy),
+		cache.Shards(cfg.Cache.Shards),
+		cache.EncodeJSON(cfg.Cache.EncodeJSON),
+		cache.OnEviction(func(_ string, _ *entity.Order, reason cache.EvictionReason) {
+			cacheEvictions.Add(string(reason), 1)
+		}),
+	), nil
+}
+
+func newRedisCache(ctx context.Context, cfg *config.Config) (*cache.RedisCache, *goredis.Client, error) {
+	codec, err := cache.ParseCodec(cfg.Redis.Codec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can not parse redis codec: %w", err)
+	}
+	redisClient, err := redis.New(
+		ctx,
+		redis.NewConfig(cfg.Redis.Host, cfg.Redis.Port, cfg.Redis.Password, cfg.Redis.DB),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cache.NewRedisCache(
+		redisClient,
+		cache.RedisPrefix(cfg.Redis.KeyPrefix),
+		cache.RedisWarmedKey(cfg.Redis.WarmedKey),
+		cache.RedisCodec(codec),
+		cache.RedisTimeout(cfg.Redis.Timeout),
+	), redisClient, nil
+}
+
 type App struct {
 	ctx           context.Context
 	httpServer    httpserver.Server
 	db            *postgres.Postgres
 	natsStreaming *nats.Streaming
+	memoryCache   *cache.MemoryCache
+	redisClient   *goredis.Client
+	listener      *postgres.Listener
+	invalidator   *cacheInvalidator
+	orderUsecase  usecase.OrderUsecase
+	router        stan.Router
+	cacheTTL      time.Duration
+	startMode     StartMode
+	snapshotPath  string
+	snapshotAge   time.Duration
 }
 
 func New(ctx context.Context) (App, error) {
 	cfg := config.Get()
 
-	memoryCache := cache.NewMemoryCache()
-	postgresInstance, err := postgres.New(
-		ctx,
-		postgres.NewConnectionConfig(
-			cfg.Postgres.Host,
-			cfg.Postgres.Port,
-			cfg.Postgres.DBName,
-			cfg.Postgres.User,
-			cfg.Postgres.Password,
-			cfg.Postgres.SSLMode,
-		),
+	orderCache, memoryCache, redisClient, err := newOrderCache(ctx, cfg)
+	if err != nil {
+		return App{}, err
+	}
+	connectionConfig := postgres.NewConnectionConfig(
+		cfg.Postgres.Host,
+		cfg.Postgres.Port,
+		cfg.Postgres.DBName,
+		cfg.Postgres.User,
+		cfg.Postgres.Password,
+		cfg.Postgres.SSLMode,
 	)
+	postgresInstance, err := postgres.New(ctx, connectionConfig)
 	if err != nil {
 		return App{}, fmt.Errorf("can not connect to postgres: %w", err)
 	}
 	orderRepository := repository.NewOrderPostgresRepository(postgresInstance)
-	orderUsecase := usecase.NewOrderUsecase(memoryCache, orderRepository)
+	duplicatePolicy, err := usecase.ParseDuplicatePolicy(cfg.Ingest.DuplicatePolicy)
+	if err != nil {
+		return App{}, fmt.Errorf("can not parse duplicate policy: %w", err)
+	}
+	orderUsecase := usecase.NewOrderUsecase(
+		orderCache,
+		orderRepository,
+		usecase.CacheTTL(cfg.Cache.TTL),
+		usecase.NegativeCacheTTL(cfg.Cache.NegativeTTL),
+		usecase.OnDuplicate(duplicatePolicy),
+		usecase.WarmupWindow(time.Duration(cfg.Warmup.Days)*24*time.Hour),
+		usecase.WarmupLimit(cfg.Warmup.Limit),
+		usecase.WarmupBatchSize(cfg.Warmup.BatchSize),
+	)
 
-	if err := orderUsecase.LoadDBToCache(ctx, time.Hour); err != nil {
-		return App{}, fmt.Errorf("can not load db to cache: %w", err)
+	// only the memory backend keeps the whole cache in process memory
+	snapshotPath := cfg.Cache.SnapshotPath
+	if cfg.Cache.Backend != "memory" {
+		snapshotPath = ""
+	}
+
+	// the shared tier of the tiered cache is kept up to date by the writers themselves
+	var (
+		listener    *postgres.Listener
+		invalidator *cacheInvalidator
+	)
+	if cfg.Cache.Invalidate && memoryCache != nil {
+		ci := newCacheInvalidator(memoryCache, orderUsecase, cfg.Cache.TTL, cfg.Cache.Backend == "memory")
+		invalidator = &ci
+		listener = postgres.NewListener(
+			connectionConfig,
+			repository.OrderChangesChannel,
+			ci.invalidate,
+			postgres.OnReconnect(ci.resync),
+		)
+	}
+
+	startMode, err := parseStartMode(cfg.STAN.StartMode)
+	if err != nil {
+		return App{}, fmt.Errorf("can not parse stan start mode: %w", err)
 	}
 
 	natsStreaming, err := nats.NewStreaming(nats.NewConfig(cfg.STAN.Host, cfg.STAN.Port, cfg.STAN.ClusterID, cfg.STAN.ClientID))
 	if err != nil {
 		return App{}, fmt.Errorf("can not connect to stan-streaming-server: %w", err)
 	}
-	router := stan.NewRouter(natsStreaming, orderUsecase)
-	if err := router.Init(ctx); err != nil {
-		return App{}, fmt.Errorf("can not init nats router: %w", err)
-	}
+	router := stan.NewRouter(natsStreaming, orderUsecase, cfg.Cache.TTL)
 
-	handler := http.NewHandler(orderUsecase)
+	var v1Options []v1.Option
+	if cfg.HTTP.CursorKey != "" {
+		v1Options = append(v1Options, v1.CursorKey([]byte(cfg.HTTP.CursorKey)))
+	}
+	handler := http.NewHandler(orderUsecase, cfg.HTTP.DebugVars, v1Options...)
 	return App{
-		ctx: ctx,
+		ctx:           ctx,
+		db:            postgresInstance,
+		natsStreaming: natsStreaming,
+		memoryCache:   memoryCache,
+		redisClient:   redisClient,
+		listener:      listener,
+		invalidator:   invalidator,
+		orderUsecase:  orderUsecase,
+		router:        router,
+		cacheTTL:      cfg.Cache.TTL,
+		startMode:     startMode,
+		snapshotPath:  snapshotPath,
+		snapshotAge:   cfg.Cache.SnapshotMaxAge,
 		httpServer: httpserver.New(
 			handler.Init(),
 			httpserver.NewConfig(
@@ -78,7 +242,8 @@ func New(ctx context.Context) (App, error) {
 }
 
 func (a App) Run() error {
-	eChan := make(chan error)
+	// both the http server and the warm-up may report an error
+	eChan := make(chan error, 2)
 	interrupt := make(chan os.Signal, 1)
 
 	logger.Info("Http server is starting")
@@ -88,18 +253,113 @@ func (a App) Run() error {
 		}
 	}()
 
+	if a.startMode == StartImmediately {
+		if err := a.router.Init(a.ctx); err != nil {
+			if stopErr := a.stopHTTPServer(); stopErr != nil {
+				logger.Errorf("can not stop http server: %v", stopErr)
+			}
+			return fmt.Errorf("can not init nats router: %w", err)
+		}
+	}
+	listenerCtx, stopListener := context.WithCancel(a.ctx)
+	defer stopListener()
+	if a.listener != nil {
+		// subscribe before the warm-up, so that no change made after it is missed
+		go a.listener.Listen(listenerCtx)
+		go a.invalidator.run(listenerCtx)
+	}
+	warmupCtx, stopWarmup := context.WithCancel(a.ctx)
+	defer stopWarmup()
+	warmupDone := make(chan struct{})
+	go func() {
+		defer close(warmupDone)
+		a.warmup(warmupCtx, eChan)
+	}()
+
 	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	var runErr error
 	select {
 	case err := <-eChan:
-		return fmt.Errorf("wb-l0 started failed: %w", err)
+		runErr = fmt.Errorf("wb-l0 started failed: %w", err)
 	case <-interrupt:
 	}
 
-	const httpShutdownTimeout = 5 * time.Second
-	if err := a.httpServer.Stop(a.ctx, httpShutdownTimeout); err != nil {
-		return err
+	if err := a.stopHTTPServer(); err != nil && runErr == nil {
+		runErr = err
 	}
-	a.db.Close()
+	// the warm-up uses the pool and may subscribe to STAN, so it is over before they are closed
+	stopWarmup()
+	<-warmupDone
 	a.natsStreaming.UnsubscribeAll()
-	return a.natsStreaming.Close()
+	stopListener()
+	if a.snapshotPath != "" {
+		a.saveSnapshot()
+	}
+	if a.memoryCache != nil {
+		a.memoryCache.Close()
+	}
+	if a.redisClient != nil {
+		if err := a.redisClient.Close(); err != nil {
+			logger.Errorf("can not close redis client: %v", err)
+		}
+	}
+	a.db.Close()
+	if err := a.natsStreaming.Close(); err != nil && runErr == nil {
+		runErr = err
+	}
+	return runErr
+}
+
+func (a App) stopHTTPServer() error {
+	const httpShutdownTimeout = 5 * time.Second
+	return a.httpServer.Stop(a.ctx, httpShutdownTimeout)
+}
+
+// warmup loads the cache in the background. The app keeps serving if the warm-up fails,
+// lookups just fall through to the repository. In the after-warmup mode it subscribes to STAN
+// unless the app is stopped first.
+func (a App) warmup(ctx context.Context, eChan chan<- error) {
+	if err := a.restoreCache(ctx); err != nil {
+		logger.Errorf("can not load db to cache: %v", err)
+	}
+
+	if a.startMode == StartAfterWarmup && ctx.Err() == nil {
+		if err := a.router.Init(a.ctx); err != nil {
+			eChan <- fmt.Errorf("can not init nats router: %w", err)
+		}
+	}
+}
+
+// saveSnapshot saves the cache unless the warm-up is unfinished: a partial cache restored later
+// would be reconciled as a complete one and miss the orders the warm-up did not get to.
+func (a App) saveSnapshot() {
+	if state := a.orderUsecase.WarmupStatus().State; state != usecase.WarmupDone {
+		logger.Warnf("Cache snapshot is not saved, the warm-up is %s", state)
+		return
+	}
+
+	if err := a.memoryCache.SaveSnapshot(a.snapshotPath); err != nil {
+		logger.Errorf("can not save cache snapshot: %v", err)
+	} else {
+		logger.Infof("Cache snapshot saved to %s", a.snapshotPath)
+	}
+}
+
+// restoreCache restores the cache snapshot and reconciles it with the orders changed after it.
+// Without a usable snapshot the whole warm-up runs.
+func (a App) restoreCache(ctx context.Context) error {
+	// change times come from the database clock, the snapshot time from the app one
+	const clockSkew = time.Minute
+
+	if a.snapshotPath != "" {
+		takenAt, err := a.memoryCache.LoadSnapshot(a.snapshotPath, a.snapshotAge)
+		if err == nil {
+			logger.Infof("Cache snapshot taken at %s restored, %d orders", takenAt, a.memoryCache.Len())
+			return a.orderUsecase.ReconcileCache(ctx, a.cacheTTL, takenAt.Add(-clockSkew))
+		}
+		logger.Warnf("can not restore cache snapshot, falling back to full warm-up: %v", err)
+	}
+
+	logger.Info("Cache warm-up is starting")
+	return a.orderUsecase.LoadDBToCache(ctx, a.cacheTTL)
 }
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/cache"
+	"github.com/maypok86/wb-l0/internal/repository"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/maypok86/wb-l0/pkg/logger"
+)
+
+// cacheInvalidator keeps the in-process cache in line with the orders changed through other replicas.
+type cacheInvalidator struct {
+	memoryCache  *cache.MemoryCache
+	orderUsecase usecase.OrderUsecase
+	cacheTTL     time.Duration
+	// reload is set when the memory cache is the only tier and has to be loaded again after a resync
+	reload bool
+	// resyncs holds at most one pending reload, requests made while one is pending are merged into it
+	resyncs chan struct{}
+}
+
+func newCacheInvalidator(
+	memoryCache *cache.MemoryCache,
+	orderUsecase usecase.OrderUsecase,
+	cacheTTL time.Duration,
+	reload bool,
+) cacheInvalidator {
+	return cacheInvalidator{
+		memoryCache:  memoryCache,
+		orderUsecase: orderUsecase,
+		cacheTTL:     cacheTTL,
+		reload:       reload,
+		resyncs:      make(chan struct{}, 1),
+	}
+}
+
+// invalidate evicts the changed order unless the cache already holds its new version, as it does
+// after changes made through this replica. Evicted orders are read from the repository on the next lookup.
+func (ci cacheInvalidator) invalidate(payload string) {
+	change := repository.ParseOrderChange(payload)
+	ci.orderUsecase.ForgetMissingOrder(change.OrderUID)
+
+	if change.Version > 0 {
+		if version, ok := ci.memoryCache.Version(change.OrderUID); ok && version >= change.Version {
+			return
+		}
+	}
+	if err := ci.memoryCache.Delete(change.OrderUID); err != nil {
+		logger.Errorf("can not evict changed order %s: %v", change.OrderUID, err)
+	}
+}
+
+// resync drops the in-process cache after notifications may have been missed.
+// An L1 just fills up from the shared tier again, a memory cache is reloaded by run.
+func (ci cacheInvalidator) resync(context.Context) {
+	logger.Warn("Order change notifications may have been missed, resyncing the cache")
+	if !ci.reload {
+		ci.memoryCache.Clear()
+		return
+	}
+
+	select {
+	case ci.resyncs <- struct{}{}:
+	default:
+	}
+}
+
+// run reloads the memory cache on the resyncs requested until ctx is done. Reloads happen outside
+// of the listener, so notifications keep being handled meanwhile.
+func (ci cacheInvalidator) run(ctx context.Context) {
+	for {
+		select {
+		case <-ci.resyncs:
+			if err := ci.orderUsecase.ResyncCache(ctx, ci.cacheTTL); err != nil {
+				logger.Errorf("can not resync cache: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/cache"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/maypok86/wb-l0/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("fatal")
+	os.Exit(m.Run())
+}
+
+// reloadRepository serves empty reloads, each one waits for release after announcing itself on started.
+type reloadRepository struct {
+	usecase.OrderRepository
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r reloadRepository) CountOrders(context.Context, usecase.OrderBatch) (int, error) {
+	r.started <- struct{}{}
+	<-r.release
+	return 0, nil
+}
+
+func (r reloadRepository) GetOrdersBatch(context.Context, usecase.OrderBatch) ([]*entity.Order, error) {
+	return nil, nil
+}
+
+func newTestInvalidator(t *testing.T, repository usecase.OrderRepository, reload bool) cacheInvalidator {
+	t.Helper()
+
+	memoryCache := cache.NewMemoryCache()
+	t.Cleanup(memoryCache.Close)
+	orderUsecase := usecase.NewOrderUsecase(memoryCache, repository)
+	return newCacheInvalidator(memoryCache, orderUsecase, time.Minute, reload)
+}
+
+func TestCacheInvalidator_invalidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		evicted bool
+	}{
+		{
+			name:    "newer version",
+			payload: "uid:3",
+			evicted: true,
+		},
+		{
+			name:    "same version",
+			payload: "uid:2",
+			evicted: false,
+		},
+		{
+			name:    "older version",
+			payload: "uid:1",
+			evicted: false,
+		},
+		{
+			name:    "delete",
+			payload: "uid",
+			evicted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ci := newTestInvalidator(t, nil, false)
+			require.NoError(t, ci.memoryCache.Set("uid", &entity.Order{OrderUID: "uid", Version: 2}, time.Minute))
+
+			ci.invalidate(tt.payload)
+
+			_, err := ci.memoryCache.Get("uid")
+			if tt.evicted {
+				require.ErrorIs(t, err, cache.ErrItemNotFound)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCacheInvalidator_resyncClearsL1(t *testing.T) {
+	ci := newTestInvalidator(t, nil, false)
+	require.NoError(t, ci.memoryCache.Set("uid", &entity.Order{OrderUID: "uid", Version: 1}, time.Minute))
+
+	ci.resync(context.Background())
+
+	require.Zero(t, ci.memoryCache.Len())
+	require.Len(t, ci.resyncs, 0)
+}
+
+func TestCacheInvalidator_run(t *testing.T) {
+	repository := reloadRepository{started: make(chan struct{}), release: make(chan struct{})}
+	ci := newTestInvalidator(t, repository, true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		ci.run(ctx)
+		close(done)
+	}()
+
+	ci.resync(ctx)
+	<-repository.started
+
+	// the resyncs requested during a reload are merged into a single next one
+	for i := 0; i < 3; i++ {
+		ci.resync(ctx)
+	}
+	require.Len(t, ci.resyncs, 1)
+
+	repository.release <- struct{}{}
+	<-repository.started
+	require.Len(t, ci.resyncs, 0)
+	repository.release <- struct{}{}
+
+	cancel()
+	<-done
+	select {
+	case <-repository.started:
+		t.Fatal("unexpected reload")
+	default:
+	}
+}
@@ -0,0 +1,14 @@
+package cache
+
+import "time"
+
+// Clock tells the current time. Tests use it to move time forward without sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
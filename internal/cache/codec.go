@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+// Codec serializes orders stored outside of the process memory.
+type Codec interface {
+	Marshal(*entity.Order) ([]byte, error)
+	Unmarshal([]byte, *entity.Order) error
+}
+
+func ParseCodec(codec string) (Codec, error) {
+	switch codec {
+	case "json":
+		return JSONCodec{}, nil
+	case "gzip":
+		return GzipCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache codec %q", codec)
+	}
+}
+
+// JSONCodec stores orders in their API representation.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(order *entity.Order) ([]byte, error) {
+	return json.Marshal(order)
+}
+
+func (JSONCodec) Unmarshal(data []byte, order *entity.Order) error {
+	return json.Unmarshal(data, order)
+}
+
+// GzipCodec stores gzipped JSON. It trades CPU for memory and network, orders compress several times.
+type GzipCodec struct{}
+
+func (GzipCodec) Marshal(order *entity.Order) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(order); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GzipCodec) Unmarshal(data []byte, order *entity.Order) error {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	decoded, err := io.ReadAll(zr)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, order)
+}
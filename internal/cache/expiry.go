@@ -0,0 +1,39 @@
+package cache
+
+import "container/heap"
+
+// expiryQueue is a min-heap of items ordered by their deadlines.
+// It lets the janitor evict expired items without scanning the whole cache.
+type expiryQueue []*item
+
+var _ heap.Interface = (*expiryQueue)(nil)
+
+func (q expiryQueue) Len() int {
+	return len(q)
+}
+
+func (q expiryQueue) Less(i, j int) bool {
+	return q[i].expiresAt.Before(q[j].expiresAt)
+}
+
+func (q expiryQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *expiryQueue) Push(x interface{}) {
+	it := x.(*item)
+	it.index = len(*q)
+	*q = append(*q, it)
+}
+
+func (q *expiryQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	it.index = notQueued
+	*q = old[:n-1]
+	return it
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"sort"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+// orderIndex maps a lookup field value to the keys of the entries holding it.
+type orderIndex map[entity.LookupField]map[string]map[string]struct{}
+
+func newOrderIndex() orderIndex {
+	index := make(orderIndex, len(entity.LookupFields))
+	for _, field := range entity.LookupFields {
+		index[field] = make(map[string]map[string]struct{})
+	}
+	return index
+}
+
+func (oi orderIndex) add(key string, order *entity.Order) {
+	for field, values := range oi {
+		for _, value := range order.LookupValues(field) {
+			keys, ok := values[value]
+			if !ok {
+				keys = make(map[string]struct{}, 1)
+				values[value] = keys
+			}
+			keys[key] = struct{}{}
+		}
+	}
+}
+
+func (oi orderIndex) remove(key string, order *entity.Order) {
+	for field, values := range oi {
+		for _, value := range order.LookupValues(field) {
+			keys := values[value]
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(values, value)
+			}
+		}
+	}
+}
+
+// sortNewestFirst orders the lookup results like the repository does.
+func sortNewestFirst(orders []*entity.Order) {
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].DateCreated.Equal(orders[j].DateCreated) {
+			return orders[i].DateCreated.After(orders[j].DateCreated)
+		}
+		return orders[i].OrderUID > orders[j].OrderUID
+	})
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func indexedOrder(uid, trackNumber, customerID string, rids ...string) *entity.Order {
+	order := &entity.Order{
+		OrderUID:    uid,
+		TrackNumber: trackNumber,
+		CustomerID:  customerID,
+		Payment:     entity.Payment{Transaction: uid},
+		DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+	}
+	for _, rid := range rids {
+		order.Items = append(order.Items, entity.Item{TrackNumber: trackNumber, Rid: rid})
+	}
+	return order
+}
+
+func lookupUIDs(t *testing.T, c *MemoryCache, field entity.LookupField, value string) []string {
+	t.Helper()
+
+	orders, err := c.Lookup(field, value)
+	require.NoError(t, err)
+	uids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		uids = append(uids, order.OrderUID)
+	}
+	return uids
+}
+
+func TestMemoryCache_Lookup(t *testing.T) {
+	c := NewMemoryCache(CleanupInterval(time.Hour))
+	defer c.Close()
+
+	first := indexedOrder("first", "TRACK1", "customer", "rid1", "rid2")
+	second := indexedOrder("second", "TRACK2", "customer", "rid3")
+	second.DateCreated = first.DateCreated.Add(time.Hour)
+	require.NoError(t, c.Set(first.OrderUID, first, 0))
+	require.NoError(t, c.Set(second.OrderUID, second, 0))
+
+	require.Equal(t, []string{"first"}, lookupUIDs(t, c, entity.LookupTrackNumber, "TRACK1"))
+	require.Equal(t, []string{"second", "first"}, lookupUIDs(t, c, entity.LookupCustomerID, "customer"))
+	require.Equal(t, []string{"second"}, lookupUIDs(t, c, entity.LookupTransaction, "second"))
+	require.Equal(t, []string{"first"}, lookupUIDs(t, c, entity.LookupRid, "rid2"))
+	require.Empty(t, lookupUIDs(t, c, entity.LookupTrackNumber, "TRACK3"))
+}
+
+func TestMemoryCache_LookupConsistency(t *testing.T) {
+	c, clock := newTestCache(t)
+
+	require.NoError(t, c.Set("order", indexedOrder("order", "OLD", "customer", "rid"), time.Minute))
+	require.Equal(t, []string{"order"}, lookupUIDs(t, c, entity.LookupTrackNumber, "OLD"))
+
+	// an update reindexes the entry
+	require.NoError(t, c.Set("order", indexedOrder("order", "NEW", "customer"), time.Minute))
+	require.Empty(t, lookupUIDs(t, c, entity.LookupTrackNumber, "OLD"))
+	require.Empty(t, lookupUIDs(t, c, entity.LookupRid, "rid"))
+	require.Equal(t, []string{"order"}, lookupUIDs(t, c, entity.LookupTrackNumber, "NEW"))
+
+	// expired entries are not found even before the janitor runs
+	clock.Advance(time.Minute)
+	require.Empty(t, lookupUIDs(t, c, entity.LookupTrackNumber, "NEW"))
+
+	c.evictExpired()
+	require.Empty(t, c.shards[0].index[entity.LookupTrackNumber])
+	require.Empty(t, c.shards[0].index[entity.LookupCustomerID])
+
+	require.NoError(t, c.Set("order", indexedOrder("order", "NEW", "customer"), 0))
+	require.NoError(t, c.Delete("order"))
+	require.Empty(t, c.shards[0].index[entity.LookupTrackNumber])
+}
+
+func TestMemoryCache_LookupEviction(t *testing.T) {
+	c, _, _ := newBoundedCache(t, MaxEntries(1))
+
+	require.NoError(t, c.Set("first", indexedOrder("first", "TRACK1", "customer"), 0))
+	require.NoError(t, c.Set("second", indexedOrder("second", "TRACK2", "customer"), 0))
+
+	require.Empty(t, lookupUIDs(t, c, entity.LookupTrackNumber, "TRACK1"))
+	require.Equal(t, []string{"second"}, lookupUIDs(t, c, entity.LookupCustomerID, "customer"))
+}
@@ -0,0 +1,77 @@
+package cache
+
+import "container/heap"
+
+// lfu evicts the least frequently used item, the least recently used one among equally frequent items.
+type lfu struct {
+	items lfuQueue
+	clock uint64
+}
+
+func newLFU() *lfu {
+	return &lfu{}
+}
+
+func (p *lfu) tick(it *item) {
+	p.clock++
+	it.lastAccess = p.clock
+}
+
+func (p *lfu) add(it *item) {
+	it.frequency = 1
+	p.tick(it)
+	heap.Push(&p.items, it)
+}
+
+func (p *lfu) access(it *item) {
+	it.frequency++
+	p.tick(it)
+	heap.Fix(&p.items, it.policyIndex)
+}
+
+func (p *lfu) remove(it *item) {
+	heap.Remove(&p.items, it.policyIndex)
+}
+
+func (p *lfu) victim() *item {
+	if len(p.items) == 0 {
+		return nil
+	}
+	return p.items[0]
+}
+
+type lfuQueue []*item
+
+var _ heap.Interface = (*lfuQueue)(nil)
+
+func (q lfuQueue) Len() int {
+	return len(q)
+}
+
+func (q lfuQueue) Less(i, j int) bool {
+	if q[i].frequency != q[j].frequency {
+		return q[i].frequency < q[j].frequency
+	}
+	return q[i].lastAccess < q[j].lastAccess
+}
+
+func (q lfuQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].policyIndex = i
+	q[j].policyIndex = j
+}
+
+func (q *lfuQueue) Push(x interface{}) {
+	it := x.(*item)
+	it.policyIndex = len(*q)
+	*q = append(*q, it)
+}
+
+func (q *lfuQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return it
+}
@@ -1,7 +1,10 @@
 package cache
 
 import (
+	"container/list"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -10,59 +13,226 @@ import (
 
 var ErrItemNotFound = errors.New("cache: item not found")
 
+// notQueued is the index of an item that never expires and so is absent from the expiry queue.
+const notQueued = -1
+
+type EvictionReason string
+
+const (
+	EvictionExpired  EvictionReason = "expired"
+	EvictionCapacity EvictionReason = "capacity"
+)
+
+// EvictionFunc is called for every entry removed by the cache itself, outside of the cache lock.
+type EvictionFunc func(key string, value *entity.Order, reason EvictionReason)
+
+// item values are copies owned by the cache and never modified, so they may be read outside of the shard lock.
 type item struct {
+	key       string
 	value     *entity.Order
-	createdAt int64
-	ttl       int64
+	encoded   []byte
+	cost      int64
+	expiresAt time.Time
+	index     int
+
+	// eviction policy bookkeeping
+	element     *list.Element
+	segment     segment
+	frequency   uint64
+	lastAccess  uint64
+	policyIndex int
+}
+
+func (i *item) expired(now time.Time) bool {
+	return i.index != notQueued && !now.Before(i.expiresAt)
 }
 
+type eviction struct {
+	key    string
+	value  *entity.Order
+	reason EvictionReason
+}
+
+// MemoryCache is split into hash-partitioned shards, so operations on different keys rarely wait for each other.
+// The entry bounds are split evenly between the shards, see shardCount.
 type MemoryCache struct {
-	mutex sync.RWMutex
-	cache map[string]*item
+	shards     []*shard
+	mask       uint64
+	done       chan struct{}
+	once       sync.Once
+	onEviction EvictionFunc
 }
 
-func NewMemoryCache() *MemoryCache {
-	c := &MemoryCache{cache: make(map[string]*item)}
-	go c.setTTLTimer()
+func NewMemoryCache(opts ...Option) *MemoryCache {
+	cfg := getDefaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	count := shardCount(cfg)
+	shards := make([]*shard, count)
+	for i := range shards {
+		shards[i] = newShard(cfg, cfg.maxEntries/count, cfg.maxBytes/int64(count))
+	}
+
+	c := &MemoryCache{
+		shards:     shards,
+		mask:       uint64(count - 1),
+		done:       make(chan struct{}),
+		onEviction: cfg.onEviction,
+	}
+	go c.runJanitor(cfg.cleanupInterval)
 
 	return c
 }
 
-func (c *MemoryCache) setTTLTimer() {
+// minShardBytes is the least memory bound of a shard, enough for dozens of typical orders.
+const minShardBytes = 64 << 10
+
+// shardCount halves the configured number of shards until every shard holds at least one entry
+// and minShardBytes, so a small bound split between many shards neither exceeds the bound
+// nor leaves the shards too small for an order.
+func shardCount(cfg *config) int {
+	count := cfg.shards
+	for count > 1 && (cfg.maxEntries > 0 && cfg.maxEntries < count ||
+		cfg.maxBytes > 0 && cfg.maxBytes < int64(count)*minShardBytes) {
+		count >>= 1
+	}
+	return count
+}
+
+func (c *MemoryCache) shard(key string) *shard {
+	return c.shards[hashKey(key)&c.mask]
+}
+
+func (c *MemoryCache) runJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
 	for {
-		now := time.Now().Unix()
-		c.mutex.Lock()
-		for k, v := range c.cache {
-			if now-v.createdAt > v.ttl {
-				delete(c.cache, k)
-			}
+		select {
+		case <-ticker.C:
+			c.evictExpired()
+		case <-c.done:
+			return
 		}
-		c.mutex.Unlock()
+	}
+}
 
-		<-time.After(time.Second)
+// evictExpired removes expired items shard by shard, so only one shard is locked at a time.
+func (c *MemoryCache) evictExpired() {
+	for _, s := range c.shards {
+		c.notify(s.evictExpired())
 	}
 }
 
-func (c *MemoryCache) Set(key string, value *entity.Order, ttl time.Duration) error {
-	c.mutex.Lock()
-	c.cache[key] = &item{
-		value:     value,
-		createdAt: time.Now().Unix(),
-		ttl:       int64(ttl),
+func (c *MemoryCache) notify(evictions []eviction) {
+	if c.onEviction == nil {
+		return
+	}
+	for _, e := range evictions {
+		c.onEviction(e.key, e.value.Clone(), e.reason)
 	}
-	c.mutex.Unlock()
+}
+
+// Set stores a copy of the value for ttl. A non-positive ttl means the value never expires.
+// A bounded cache may evict other entries or the value itself to stay within its bounds.
+func (c *MemoryCache) Set(key string, value *entity.Order, ttl time.Duration) error {
+	s := c.shard(key)
 
+	var encoded []byte
+	if s.encodeJSON {
+		var err error
+		if encoded, err = json.Marshal(value); err != nil {
+			return fmt.Errorf("can not encode order: %w", err)
+		}
+	}
+	c.notify(s.set(key, value.Clone(), encoded, ttl))
 	return nil
 }
 
+// Get returns a copy of the value stored for key, so callers are free to modify it.
+// Expired values are reported as missing even before the janitor evicts them.
 func (c *MemoryCache) Get(key string) (*entity.Order, error) {
-	c.mutex.RLock()
-	item, ok := c.cache[key]
-	c.mutex.RUnlock()
-
+	value, _, ok := c.shard(key).get(key)
 	if !ok {
 		return nil, ErrItemNotFound
 	}
+	return value.Clone(), nil
+}
+
+// GetJSON returns the JSON encoding of the value stored for key along with its version. The encoding is
+// a copy, and it is the one kept by the cache if EncodeJSON is set, otherwise the value is encoded on read.
+func (c *MemoryCache) GetJSON(key string) ([]byte, int, error) {
+	value, encoded, ok := c.shard(key).get(key)
+	if !ok {
+		return nil, 0, ErrItemNotFound
+	}
+	if encoded == nil {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("can not encode order: %w", err)
+		}
+		return data, value.Version, nil
+	}
+
+	data := make([]byte, len(encoded))
+	copy(data, encoded)
+	return data, value.Version, nil
+}
+
+// Version returns the version of the value stored for key. Unlike Get it neither copies the value
+// nor counts as an access for the eviction policy.
+func (c *MemoryCache) Version(key string) (int, bool) {
+	return c.shard(key).version(key)
+}
+
+// Lookup returns the orders holding value in the field, newest first. Secondary indexes follow every set,
+// deletion, expiry and eviction, so only the orders Get would return are found.
+func (c *MemoryCache) Lookup(field entity.LookupField, value string) ([]*entity.Order, error) {
+	var orders []*entity.Order
+	for _, s := range c.shards {
+		for _, order := range s.lookup(field, value) {
+			orders = append(orders, order.Clone())
+		}
+	}
+	sortNewestFirst(orders)
+	return orders, nil
+}
+
+func (c *MemoryCache) Delete(key string) error {
+	c.shard(key).delete(key)
+	return nil
+}
+
+// Clear removes all entries. Removed entries are not reported to the eviction callback.
+func (c *MemoryCache) Clear() {
+	for _, s := range c.shards {
+		s.clear()
+	}
+}
+
+// Keys returns the keys of the entries which are not expired.
+func (c *MemoryCache) Keys() []string {
+	var keys []string
+	for _, s := range c.shards {
+		keys = s.appendKeys(keys)
+	}
+	return keys
+}
+
+// Len returns the number of stored entries including expired ones not evicted yet.
+func (c *MemoryCache) Len() int {
+	length := 0
+	for _, s := range c.shards {
+		length += s.len()
+	}
+	return length
+}
 
-	return item.value, nil
+// Close stops the janitor. The cache stays usable, expired values are still never returned.
+func (c *MemoryCache) Close() {
+	c.once.Do(func() {
+		close(c.done)
+	})
 }
@@ -1,6 +1,9 @@
 package cache
 
 import (
+	"encoding/json"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -9,8 +12,40 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2022, 6, 29, 16, 56, 58, 0, time.UTC)}
+}
+
+func (fc *fakeClock) Now() time.Time {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	return fc.now
+}
+
+func (fc *fakeClock) Advance(d time.Duration) {
+	fc.mutex.Lock()
+	fc.now = fc.now.Add(d)
+	fc.mutex.Unlock()
+}
+
+func newTestCache(t *testing.T) (*MemoryCache, *fakeClock) {
+	t.Helper()
+
+	clock := newFakeClock()
+	c := NewMemoryCache(WithClock(clock), CleanupInterval(time.Hour), Shards(1))
+	t.Cleanup(c.Close)
+	return c, clock
+}
+
 func TestNewMemoryCache(t *testing.T) {
 	c := NewMemoryCache()
+	defer c.Close()
 	require.NotNil(t, c)
 }
 
@@ -31,16 +66,17 @@ func fakeKey(t *testing.T) string {
 }
 
 func TestMemoryCache_Get(t *testing.T) {
-	c := NewMemoryCache()
+	c, _ := newTestCache(t)
 	order := fakeOrder(t)
 	key := fakeKey(t)
 	gotOrder, err := c.Get(key)
 	require.Error(t, err)
 	require.Nil(t, gotOrder)
-	c.cache[key] = &item{
+	c.shards[0].items[key] = &item{
+		key:       key,
 		value:     order,
-		createdAt: time.Now().Unix(),
-		ttl:       61,
+		expiresAt: c.shards[0].clock.Now().Add(time.Minute),
+		index:     notQueued,
 	}
 	gotOrder, err = c.Get(key)
 	require.NoError(t, err)
@@ -48,25 +84,216 @@ func TestMemoryCache_Get(t *testing.T) {
 }
 
 func TestMemoryCache_Set(t *testing.T) {
-	c := NewMemoryCache()
+	c, _ := newTestCache(t)
 	order := fakeOrder(t)
 	key := fakeKey(t)
-	require.NoError(t, c.Set(key, order, 1))
+	require.NoError(t, c.Set(key, order, time.Minute))
 	gotOrder, err := c.Get(key)
 	require.NoError(t, err)
 	require.Equal(t, order, gotOrder)
 }
 
+func TestMemoryCache_Delete(t *testing.T) {
+	c, _ := newTestCache(t)
+	order := fakeOrder(t)
+	key := fakeKey(t)
+	require.NoError(t, c.Set(key, order, time.Minute))
+	require.NoError(t, c.Delete(key))
+	gotOrder, err := c.Get(key)
+	require.Error(t, err)
+	require.Nil(t, gotOrder)
+	require.Empty(t, c.shards[0].expiry)
+	require.NoError(t, c.Delete(key))
+}
+
+func TestMemoryCache_Keys(t *testing.T) {
+	c, clock := newTestCache(t)
+
+	require.NoError(t, c.Set("a", snapshotOrder("a"), time.Minute))
+	require.NoError(t, c.Set("b", snapshotOrder("b"), time.Hour))
+	require.NoError(t, c.Set("c", snapshotOrder("c"), 0))
+	clock.Advance(2 * time.Minute)
+
+	require.ElementsMatch(t, []string{"b", "c"}, c.Keys())
+}
+
 func TestMemoryCache_Clean(t *testing.T) {
-	c := NewMemoryCache()
+	c, clock := newTestCache(t)
 	order := fakeOrder(t)
 	key := fakeKey(t)
-	require.NoError(t, c.Set(key, order, 1))
+	require.NoError(t, c.Set(key, order, time.Second))
 	gotOrder, err := c.Get(key)
 	require.NoError(t, err)
 	require.Equal(t, order, gotOrder)
-	time.Sleep(2 * time.Second)
+	clock.Advance(2 * time.Second)
 	gotOrder, err = c.Get(key)
 	require.Error(t, err)
 	require.Nil(t, gotOrder)
+
+	c.evictExpired()
+	require.Empty(t, c.shards[0].items)
+	require.Empty(t, c.shards[0].expiry)
+}
+
+func TestMemoryCache_EvictExpired(t *testing.T) {
+	c, clock := newTestCache(t)
+	ttls := map[string]time.Duration{
+		"a": 3 * time.Second,
+		"b": time.Second,
+		"c": 2 * time.Second,
+		"d": 0,
+	}
+	for key, ttl := range ttls {
+		require.NoError(t, c.Set(key, fakeOrder(t), ttl))
+	}
+	// refreshing moves the deadline of b past the one of c
+	clock.Advance(time.Second / 2)
+	require.NoError(t, c.Set("b", fakeOrder(t), 2*time.Second))
+
+	steps := []struct {
+		advance time.Duration
+		left    []string
+	}{
+		{advance: time.Second, left: []string{"a", "b", "c", "d"}},
+		{advance: 3 * time.Second / 4, left: []string{"a", "b", "d"}},
+		{advance: time.Second, left: []string{"d"}},
+	}
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		c.evictExpired()
+
+		left := make([]string, 0, len(c.shards[0].items))
+		for key := range c.shards[0].items {
+			left = append(left, key)
+		}
+		require.ElementsMatch(t, step.left, left)
+		require.Len(t, c.shards[0].expiry, len(step.left)-1)
+	}
+}
+
+func TestMemoryCache_SetWithoutExpiry(t *testing.T) {
+	c, clock := newTestCache(t)
+	order := fakeOrder(t)
+	key := fakeKey(t)
+	require.NoError(t, c.Set(key, fakeOrder(t), time.Second))
+	require.NoError(t, c.Set(key, order, 0))
+	require.Empty(t, c.shards[0].expiry)
+
+	clock.Advance(time.Hour)
+	c.evictExpired()
+	gotOrder, err := c.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, order, gotOrder)
+}
+
+func TestMemoryCache_ValuesAreCopies(t *testing.T) {
+	c, _ := newTestCache(t)
+	order := snapshotOrder("order")
+	want := snapshotOrder("order")
+
+	require.NoError(t, c.Set("order", order, 0))
+	order.TrackNumber = "CHANGED"
+	order.Items[0].Name = "changed"
+
+	got, err := c.Get("order")
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	got.Items[0].Name = "changed"
+	got.StatusHistory = append(got.StatusHistory, entity.StatusChange{Status: entity.StatusPaid})
+	found, err := c.Lookup(entity.LookupTrackNumber, want.TrackNumber)
+	require.NoError(t, err)
+	require.Equal(t, []*entity.Order{want}, found)
+}
+
+// TestMemoryCache_ConcurrentMutation is meant to be run with -race: readers modifying their copies
+// must not race with each other or with the cache.
+func TestMemoryCache_ConcurrentMutation(t *testing.T) {
+	c := NewMemoryCache(CleanupInterval(time.Millisecond))
+	defer c.Close()
+
+	want := snapshotOrder("order")
+	require.NoError(t, c.Set("order", want, time.Hour))
+
+	const readers = 8
+	var wg sync.WaitGroup
+	wg.Add(readers)
+	for i := 0; i < readers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				order, err := c.Get("order")
+				require.NoError(t, err)
+				require.Equal(t, want.TrackNumber, order.TrackNumber)
+				require.Equal(t, want.Items[0].Name, order.Items[0].Name)
+
+				order.TrackNumber = "CHANGED"
+				order.Items[0].Name = "changed"
+				order.Payment.Amount = entity.NewMoney(0, "USD")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMemoryCache_GetJSON(t *testing.T) {
+	for _, encodeJSON := range []bool{false, true} {
+		t.Run(fmt.Sprintf("encode %t", encodeJSON), func(t *testing.T) {
+			c := NewMemoryCache(CleanupInterval(time.Hour), EncodeJSON(encodeJSON))
+			defer c.Close()
+
+			_, _, err := c.GetJSON("order")
+			require.ErrorIs(t, err, ErrItemNotFound)
+
+			order := snapshotOrder("order")
+			want, err := json.Marshal(order)
+			require.NoError(t, err)
+			require.NoError(t, c.Set("order", order, 0))
+
+			data, version, err := c.GetJSON("order")
+			require.NoError(t, err)
+			require.Equal(t, want, data)
+			require.Equal(t, order.Version, version)
+
+			// the returned bytes are a copy
+			data[0] = 'x'
+			data, _, err = c.GetJSON("order")
+			require.NoError(t, err)
+			require.Equal(t, want, data)
+		})
+	}
+}
+
+func TestMemoryCache_EncodedJSONCost(t *testing.T) {
+	order := snapshotOrder("order")
+	encoded, err := json.Marshal(order)
+	require.NoError(t, err)
+
+	c, _, _ := newBoundedCache(t, EncodeJSON(true), MaxBytes(sizeOf(order)+int64(len(encoded))))
+	require.NoError(t, c.Set("first", order, 0))
+	require.Equal(t, 1, c.Len())
+
+	require.NoError(t, c.Set("second", snapshotOrder("order"), 0))
+	require.Equal(t, 1, c.Len())
+	_, err = c.Get("first")
+	require.ErrorIs(t, err, ErrItemNotFound)
+}
+
+func TestMemoryCache_Clear(t *testing.T) {
+	c, _, evictions := newBoundedCache(t, MaxEntries(10))
+
+	require.NoError(t, c.Set("first", indexedOrder("first", "TRACK1", "customer"), time.Minute))
+	require.NoError(t, c.Set("second", indexedOrder("second", "TRACK2", "customer"), 0))
+	c.Clear()
+
+	require.Equal(t, 0, c.Len())
+	require.Empty(t, c.shards[0].expiry)
+	require.Zero(t, c.shards[0].bytes)
+	require.Empty(t, c.shards[0].index[entity.LookupCustomerID])
+	require.Empty(t, *evictions)
+
+	require.NoError(t, c.Set("first", indexedOrder("first", "TRACK1", "customer"), time.Minute))
+	_, err := c.Get("first")
+	require.NoError(t, err)
 }
@@ -0,0 +1,92 @@
+package cache
+
+import "time"
+
+const defaultShards = 32
+
+type config struct {
+	clock           Clock
+	cleanupInterval time.Duration
+	shards          int
+	maxEntries      int
+	maxBytes        int64
+	policy          Policy
+	onEviction      EvictionFunc
+	encodeJSON      bool
+}
+
+func getDefaultConfig() *config {
+	return &config{
+		clock:           realClock{},
+		cleanupInterval: time.Second,
+		shards:          defaultShards,
+		policy:          PolicyLRU,
+	}
+}
+
+type Option func(*config)
+
+// WithClock sets the clock used to compute and check expiry deadlines.
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// CleanupInterval sets how often expired entries are evicted. Expired entries are never returned
+// by Get regardless of the interval, it only bounds how long they occupy memory.
+func CleanupInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.cleanupInterval = interval
+		}
+	}
+}
+
+// MaxEntries bounds the number of entries. Zero means no bound.
+func MaxEntries(maxEntries int) Option {
+	return func(c *config) {
+		c.maxEntries = maxEntries
+	}
+}
+
+// MaxBytes bounds the estimated memory held by the entries. Zero means no bound.
+func MaxBytes(maxBytes int64) Option {
+	return func(c *config) {
+		c.maxBytes = maxBytes
+	}
+}
+
+// EvictionPolicy sets the policy choosing the entries evicted from a bounded cache.
+func EvictionPolicy(policy Policy) Option {
+	return func(c *config) {
+		c.policy = policy
+	}
+}
+
+// OnEviction sets the callback called for entries removed because they expired or didn't fit into the cache.
+func OnEviction(onEviction EvictionFunc) Option {
+	return func(c *config) {
+		c.onEviction = onEviction
+	}
+}
+
+// Shards sets the number of cache shards. It is rounded up to a power of two
+// and lowered for small MaxEntries and MaxBytes bounds.
+func Shards(shards int) Option {
+	return func(c *config) {
+		n := 1
+		for n < shards {
+			n <<= 1
+		}
+		c.shards = n
+	}
+}
+
+// EncodeJSON makes the cache keep the JSON encoding of every order next to it, so GetJSON
+// doesn't encode the order on each read. The encoding is computed on Set and counts towards MaxBytes.
+func EncodeJSON(enabled bool) Option {
+	return func(c *config) {
+		c.encodeJSON = enabled
+	}
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// Policy selects the entries evicted when a bounded cache is full.
+type Policy string
+
+const (
+	PolicyLRU     Policy = "lru"
+	PolicyLFU     Policy = "lfu"
+	PolicyTinyLFU Policy = "tinylfu"
+)
+
+func ParsePolicy(policy string) (Policy, error) {
+	switch Policy(policy) {
+	case PolicyLRU, PolicyLFU, PolicyTinyLFU:
+		return Policy(policy), nil
+	default:
+		return "", fmt.Errorf("unknown cache eviction policy %q", policy)
+	}
+}
+
+// evictionPolicy tracks the items of a bounded cache. All methods are called under the cache write lock.
+type evictionPolicy interface {
+	// add starts tracking a new item.
+	add(it *item)
+	// access records a hit of a tracked item.
+	access(it *item)
+	// remove stops tracking the item.
+	remove(it *item)
+	// victim returns the item to evict next or nil if nothing is tracked.
+	victim() *item
+}
+
+func newEvictionPolicy(policy Policy) evictionPolicy {
+	switch policy {
+	case PolicyLFU:
+		return newLFU()
+	case PolicyTinyLFU:
+		return newTinyLFU()
+	default:
+		return newLRU()
+	}
+}
+
+type lru struct {
+	items *list.List
+}
+
+func newLRU() *lru {
+	return &lru{items: list.New()}
+}
+
+func (p *lru) add(it *item) {
+	it.element = p.items.PushFront(it)
+}
+
+func (p *lru) access(it *item) {
+	p.items.MoveToFront(it.element)
+}
+
+func (p *lru) remove(it *item) {
+	p.items.Remove(it.element)
+	it.element = nil
+}
+
+func (p *lru) victim() *item {
+	if back := p.items.Back(); back != nil {
+		return back.Value.(*item)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package cache
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	for _, policy := range []Policy{PolicyLRU, PolicyLFU, PolicyTinyLFU} {
+		parsed, err := ParsePolicy(string(policy))
+		require.NoError(t, err)
+		require.Equal(t, policy, parsed)
+	}
+
+	_, err := ParsePolicy("fifo")
+	require.Error(t, err)
+}
+
+type evicted struct {
+	key    string
+	reason EvictionReason
+}
+
+func newBoundedCache(t *testing.T, opts ...Option) (*MemoryCache, *fakeClock, *[]evicted) {
+	t.Helper()
+
+	clock := newFakeClock()
+	evictions := &[]evicted{}
+	opts = append(
+		[]Option{
+			WithClock(clock),
+			CleanupInterval(time.Hour),
+			Shards(1),
+			OnEviction(func(key string, _ *entity.Order, reason EvictionReason) {
+				*evictions = append(*evictions, evicted{key: key, reason: reason})
+			}),
+		},
+		opts...,
+	)
+	c := NewMemoryCache(opts...)
+	t.Cleanup(c.Close)
+	return c, clock, evictions
+}
+
+func TestMemoryCache_LRU(t *testing.T) {
+	c, _, evictions := newBoundedCache(t, MaxEntries(2), EvictionPolicy(PolicyLRU))
+
+	require.NoError(t, c.Set("a", fakeOrder(t), time.Minute))
+	require.NoError(t, c.Set("b", fakeOrder(t), time.Minute))
+	_, err := c.Get("a")
+	require.NoError(t, err)
+	require.NoError(t, c.Set("c", fakeOrder(t), time.Minute))
+
+	require.Equal(t, []evicted{{key: "b", reason: EvictionCapacity}}, *evictions)
+	require.Equal(t, 2, c.Len())
+	_, err = c.Get("b")
+	require.ErrorIs(t, err, ErrItemNotFound)
+}
+
+func TestMemoryCache_Version(t *testing.T) {
+	c, clock, evictions := newBoundedCache(t, MaxEntries(2), EvictionPolicy(PolicyLRU))
+
+	order := fakeOrder(t)
+	order.Version = 3
+	require.NoError(t, c.Set("a", order, time.Minute))
+	require.NoError(t, c.Set("b", fakeOrder(t), time.Hour))
+
+	version, ok := c.Version("a")
+	require.True(t, ok)
+	require.Equal(t, 3, version)
+	_, ok = c.Version("missing")
+	require.False(t, ok)
+
+	// reading the version doesn't save the least recently used entry
+	require.NoError(t, c.Set("c", fakeOrder(t), time.Hour))
+	require.Equal(t, []evicted{{key: "a", reason: EvictionCapacity}}, *evictions)
+
+	clock.Advance(2 * time.Hour)
+	_, ok = c.Version("b")
+	require.False(t, ok)
+}
+
+func TestMemoryCache_LFU(t *testing.T) {
+	c, _, evictions := newBoundedCache(t, MaxEntries(2), EvictionPolicy(PolicyLFU))
+
+	require.NoError(t, c.Set("a", fakeOrder(t), time.Minute))
+	require.NoError(t, c.Set("b", fakeOrder(t), time.Minute))
+	for i := 0; i < 3; i++ {
+		_, err := c.Get("b")
+		require.NoError(t, err)
+	}
+	_, err := c.Get("a")
+	require.NoError(t, err)
+	require.NoError(t, c.Set("c", fakeOrder(t), time.Minute))
+
+	require.Equal(t, []evicted{{key: "a", reason: EvictionCapacity}}, *evictions)
+	_, err = c.Get("b")
+	require.NoError(t, err)
+	_, err = c.Get("c")
+	require.NoError(t, err)
+}
+
+func TestMemoryCache_TinyLFU(t *testing.T) {
+	const size = 100
+	c, _, _ := newBoundedCache(t, MaxEntries(size), EvictionPolicy(PolicyTinyLFU))
+	order := fakeOrder(t)
+
+	// hot keys are read often and so should survive a scan of keys read once
+	for i := 0; i < size; i++ {
+		require.NoError(t, c.Set(fmt.Sprintf("hot-%d", i), order, time.Minute))
+	}
+	for round := 0; round < 3; round++ {
+		for i := 0; i < size; i++ {
+			_, err := c.Get(fmt.Sprintf("hot-%d", i))
+			require.NoError(t, err)
+		}
+	}
+	for i := 0; i < 10*size; i++ {
+		require.NoError(t, c.Set(fmt.Sprintf("scan-%d", i), order, time.Minute))
+	}
+
+	hits := 0
+	for i := 0; i < size; i++ {
+		if _, err := c.Get(fmt.Sprintf("hot-%d", i)); err == nil {
+			hits++
+		}
+	}
+	require.Equal(t, size, c.Len())
+	require.Greater(t, hits, size*3/4)
+}
+
+func TestMemoryCache_MaxBytes(t *testing.T) {
+	order := fakeOrder(t)
+	cost := sizeOf(order)
+	c, _, evictions := newBoundedCache(t, MaxBytes(2*cost))
+
+	require.NoError(t, c.Set("a", order, time.Minute))
+	require.NoError(t, c.Set("b", order, time.Minute))
+	require.NoError(t, c.Set("c", order, time.Minute))
+
+	require.Equal(t, []evicted{{key: "a", reason: EvictionCapacity}}, *evictions)
+	require.Equal(t, 2*cost, c.shards[0].bytes)
+
+	require.NoError(t, c.Delete("b"))
+	require.Equal(t, cost, c.shards[0].bytes)
+}
+
+func TestMemoryCache_EvictionCallbackOnExpiry(t *testing.T) {
+	c, clock, evictions := newBoundedCache(t, MaxEntries(10))
+
+	require.NoError(t, c.Set("a", fakeOrder(t), time.Second))
+	clock.Advance(time.Minute)
+	c.evictExpired()
+
+	require.Equal(t, []evicted{{key: "a", reason: EvictionExpired}}, *evictions)
+	require.Zero(t, c.shards[0].bytes)
+	require.Zero(t, c.Len())
+}
+
+const (
+	benchmarkKeys     = 100_000
+	benchmarkCapacity = 1_000
+	// zipfS is the skew of the lookups, bigger values make popular keys more popular.
+	zipfS = 1.01
+)
+
+func BenchmarkMemoryCache_Zipf(b *testing.B) {
+	order := &entity.Order{}
+	for _, policy := range []Policy{PolicyLRU, PolicyLFU, PolicyTinyLFU} {
+		b.Run(string(policy), func(b *testing.B) {
+			c := NewMemoryCache(MaxEntries(benchmarkCapacity), EvictionPolicy(policy), Shards(1))
+			defer c.Close()
+
+			zipf := rand.NewZipf(rand.New(rand.NewSource(1)), zipfS, 1, benchmarkKeys-1)
+			keys := make([]string, benchmarkKeys)
+			for i := range keys {
+				keys[i] = fmt.Sprintf("order-%d", i)
+			}
+
+			hits := 0
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				key := keys[zipf.Uint64()]
+				if _, err := c.Get(key); err == nil {
+					hits++
+					continue
+				}
+				_ = c.Set(key, order, time.Hour)
+			}
+			b.ReportMetric(float64(hits)/float64(b.N), "hit-ratio")
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+const (
+	defaultRedisPrefix    = "wb-l0:order:"
+	defaultRedisWarmedKey = "wb-l0:warmed"
+	defaultRedisTimeout   = time.Second
+)
+
+type redisConfig struct {
+	prefix    string
+	warmedKey string
+	codec     Codec
+	timeout   time.Duration
+}
+
+type RedisOption func(*redisConfig)
+
+// RedisPrefix sets the prefix of the keys orders are stored under.
+func RedisPrefix(prefix string) RedisOption {
+	return func(c *redisConfig) {
+		c.prefix = prefix
+	}
+}
+
+// RedisWarmedKey sets the key marking a finished warm-up. It must not look like an order key.
+func RedisWarmedKey(key string) RedisOption {
+	return func(c *redisConfig) {
+		c.warmedKey = key
+	}
+}
+
+// RedisCodec sets how orders are serialized.
+func RedisCodec(codec Codec) RedisOption {
+	return func(c *redisConfig) {
+		c.codec = codec
+	}
+}
+
+// RedisTimeout bounds every redis call, the cache interface doesn't carry a context.
+func RedisTimeout(timeout time.Duration) RedisOption {
+	return func(c *redisConfig) {
+		c.timeout = timeout
+	}
+}
+
+// RedisCache keeps orders in redis, so that API replicas share a single cache. Expiry is left to redis.
+type RedisCache struct {
+	client    redis.UniversalClient
+	prefix    string
+	warmedKey string
+	codec     Codec
+	timeout   time.Duration
+}
+
+func NewRedisCache(client redis.UniversalClient, opts ...RedisOption) *RedisCache {
+	cfg := &redisConfig{
+		prefix:    defaultRedisPrefix,
+		warmedKey: defaultRedisWarmedKey,
+		codec:     JSONCodec{},
+		timeout:   defaultRedisTimeout,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return &RedisCache{
+		client:    client,
+		prefix:    cfg.prefix,
+		warmedKey: cfg.warmedKey,
+		codec:     cfg.codec,
+		timeout:   cfg.timeout,
+	}
+}
+
+func (c *RedisCache) key(key string) string {
+	return c.prefix + key
+}
+
+func (c *RedisCache) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+// Set stores the value for ttl. A non-positive ttl means the value never expires.
+func (c *RedisCache) Set(key string, value *entity.Order, ttl time.Duration) error {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("can not marshal order: %w", err)
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	if err := c.client.Set(ctx, c.key(key), data, redisTTL(ttl)).Err(); err != nil {
+		return fmt.Errorf("can not set order to redis: %w", err)
+	}
+	return nil
+}
+
+// SetMany stores the orders not cached yet under their uids in a single pipelined round trip.
+// Bulk loads read their pages before writing them, so an entry written since then by another replica
+// is at least as fresh and is kept.
+func (c *RedisCache) SetMany(orders []*entity.Order, ttl time.Duration) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, order := range orders {
+			data, err := c.codec.Marshal(order)
+			if err != nil {
+				return fmt.Errorf("can not marshal order: %w", err)
+			}
+			pipe.SetNX(ctx, c.key(order.OrderUID), data, redisTTL(ttl))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("can not set orders to redis: %w", err)
+	}
+	return nil
+}
+
+func (c *RedisCache) Get(key string) (*entity.Order, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	data, err := c.client.Get(ctx, c.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not get order from redis: %w", err)
+	}
+
+	order := &entity.Order{}
+	if err := c.codec.Unmarshal(data, order); err != nil {
+		return nil, fmt.Errorf("can not unmarshal order: %w", err)
+	}
+	return order, nil
+}
+
+func (c *RedisCache) Delete(key string) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	if err := c.client.Del(ctx, c.key(key)).Err(); err != nil {
+		return fmt.Errorf("can not delete order from redis: %w", err)
+	}
+	return nil
+}
+
+// Warmed reports whether a replica finished a warm-up whose orders haven't expired yet.
+func (c *RedisCache) Warmed() (bool, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	exists, err := c.client.Exists(ctx, c.warmedKey).Result()
+	if err != nil {
+		return false, fmt.Errorf("can not check warm-up marker in redis: %w", err)
+	}
+	return exists > 0, nil
+}
+
+// MarkWarmed marks a finished warm-up for ttl, the time its orders stay in the cache.
+func (c *RedisCache) MarkWarmed(ttl time.Duration) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	if err := c.client.Set(ctx, c.warmedKey, time.Now().Unix(), redisTTL(ttl)).Err(); err != nil {
+		return fmt.Errorf("can not set warm-up marker to redis: %w", err)
+	}
+	return nil
+}
+
+// redisTTL converts the cache ttl to the redis one, where zero means no expiry.
+func redisTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return 0
+	}
+	return ttl
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisCache(t *testing.T, opts ...RedisOption) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		client.Close()
+	})
+	return NewRedisCache(client, opts...), server
+}
+
+func TestRedisCache(t *testing.T) {
+	for _, codec := range []string{"json", "gzip"} {
+		t.Run(codec, func(t *testing.T) {
+			parsed, err := ParseCodec(codec)
+			require.NoError(t, err)
+			c, server := newTestRedisCache(t, RedisCodec(parsed), RedisPrefix("test:"))
+
+			order := snapshotOrder("order")
+			_, err = c.Get("order")
+			require.ErrorIs(t, err, ErrItemNotFound)
+
+			require.NoError(t, c.Set("order", order, time.Minute))
+			require.True(t, server.Exists("test:order"))
+			require.Equal(t, time.Minute, server.TTL("test:order"))
+
+			got, err := c.Get("order")
+			require.NoError(t, err)
+			require.Equal(t, order, got)
+
+			require.NoError(t, c.Delete("order"))
+			_, err = c.Get("order")
+			require.ErrorIs(t, err, ErrItemNotFound)
+		})
+	}
+}
+
+func TestRedisCache_Expiry(t *testing.T) {
+	c, server := newTestRedisCache(t)
+
+	require.NoError(t, c.Set("expiring", snapshotOrder("expiring"), time.Second))
+	require.NoError(t, c.Set("eternal", snapshotOrder("eternal"), 0))
+	server.FastForward(2 * time.Second)
+
+	_, err := c.Get("expiring")
+	require.ErrorIs(t, err, ErrItemNotFound)
+	_, err = c.Get("eternal")
+	require.NoError(t, err)
+}
+
+func TestRedisCache_SetMany(t *testing.T) {
+	c, server := newTestRedisCache(t)
+
+	orders := []*entity.Order{snapshotOrder("a"), snapshotOrder("b"), snapshotOrder("c")}
+	require.NoError(t, c.SetMany(orders, time.Hour))
+
+	for _, order := range orders {
+		require.Equal(t, time.Hour, server.TTL(defaultRedisPrefix+order.OrderUID))
+		got, err := c.Get(order.OrderUID)
+		require.NoError(t, err)
+		require.Equal(t, order, got)
+	}
+}
+
+func TestRedisCache_SetManyKeepsCached(t *testing.T) {
+	c, _ := newTestRedisCache(t)
+
+	fresh := snapshotOrder("a")
+	fresh.Version = 2
+	require.NoError(t, c.Set("a", fresh, time.Hour))
+
+	stale := snapshotOrder("a")
+	stale.Version = 1
+	require.NoError(t, c.SetMany([]*entity.Order{stale, snapshotOrder("b")}, time.Hour))
+
+	got, err := c.Get("a")
+	require.NoError(t, err)
+	require.Equal(t, 2, got.Version)
+	_, err = c.Get("b")
+	require.NoError(t, err)
+}
+
+func TestRedisCache_Warmed(t *testing.T) {
+	c, server := newTestRedisCache(t, RedisWarmedKey("test:warmed"))
+
+	warmed, err := c.Warmed()
+	require.NoError(t, err)
+	require.False(t, warmed)
+
+	require.NoError(t, c.MarkWarmed(time.Hour))
+	warmed, err = c.Warmed()
+	require.NoError(t, err)
+	require.True(t, warmed)
+
+	// the marker expires along with the orders of the warm-up
+	server.FastForward(time.Hour)
+	warmed, err = c.Warmed()
+	require.NoError(t, err)
+	require.False(t, warmed)
+}
+
+func TestRedisCache_Unavailable(t *testing.T) {
+	c, server := newTestRedisCache(t, RedisTimeout(100*time.Millisecond))
+	server.Close()
+
+	require.Error(t, c.Set("order", snapshotOrder("order"), time.Minute))
+	_, err := c.Get("order")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrItemNotFound)
+}
+
+func TestParseCodec(t *testing.T) {
+	_, err := ParseCodec("xml")
+	require.Error(t, err)
+}
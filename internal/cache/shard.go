@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+// shard is a part of MemoryCache with its own lock, expiry queue and eviction policy.
+type shard struct {
+	mutex  sync.RWMutex
+	items  map[string]*item
+	expiry expiryQueue
+	index  orderIndex
+	clock  Clock
+
+	encodeJSON bool
+
+	// policy is nil for an unbounded shard
+	policy     evictionPolicy
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+}
+
+func newShard(cfg *config, maxEntries int, maxBytes int64) *shard {
+	s := &shard{
+		items:      make(map[string]*item),
+		index:      newOrderIndex(),
+		encodeJSON: cfg.encodeJSON,
+		clock:      cfg.clock,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+	if maxEntries > 0 || maxBytes > 0 {
+		s.policy = newEvictionPolicy(cfg.policy)
+	}
+	return s
+}
+
+// overflow tells whether the shard would exceed its bounds after adding entries taking bytes.
+func (s *shard) overflow(entries int, bytes int64) bool {
+	return (s.maxEntries > 0 && len(s.items)+entries > s.maxEntries) ||
+		(s.maxBytes > 0 && s.bytes+bytes > s.maxBytes)
+}
+
+// remove drops the item from the map and all bookkeeping structures.
+func (s *shard) remove(it *item) {
+	delete(s.items, it.key)
+	s.index.remove(it.key, it.value)
+	if it.index != notQueued {
+		heap.Remove(&s.expiry, it.index)
+	}
+	if s.policy != nil {
+		s.policy.remove(it)
+	}
+	s.bytes -= it.cost
+}
+
+// evictExpired removes the items whose deadlines have passed. It only touches expired items.
+func (s *shard) evictExpired() []eviction {
+	now := s.clock.Now()
+
+	var evictions []eviction
+	s.mutex.Lock()
+	for len(s.expiry) > 0 && s.expiry[0].expired(now) {
+		it := s.expiry[0]
+		s.remove(it)
+		evictions = append(evictions, eviction{key: it.key, value: it.value, reason: EvictionExpired})
+	}
+	s.mutex.Unlock()
+
+	return evictions
+}
+
+// evictOverflow removes the items chosen by the policy until entries taking bytes fit into the shard.
+func (s *shard) evictOverflow(entries int, bytes int64) []eviction {
+	var evictions []eviction
+	for s.policy != nil && s.overflow(entries, bytes) {
+		it := s.policy.victim()
+		if it == nil {
+			break
+		}
+		s.remove(it)
+		evictions = append(evictions, eviction{key: it.key, value: it.value, reason: EvictionCapacity})
+	}
+	return evictions
+}
+
+// set stores the value along with its JSON encoding, which may be nil if the cache doesn't keep it.
+func (s *shard) set(key string, value *entity.Order, encoded []byte, ttl time.Duration) []eviction {
+	cost := sizeOf(value) + int64(len(encoded))
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var evictions []eviction
+	it, ok := s.items[key]
+	if !ok {
+		// Room is made before the insert, otherwise LFU would always pick the new item with its single hit.
+		evictions = s.evictOverflow(1, cost)
+		it = &item{key: key, index: notQueued}
+		s.items[key] = it
+	} else {
+		s.index.remove(key, it.value)
+	}
+	it.value = value
+	it.encoded = encoded
+	s.index.add(key, value)
+	s.bytes += cost - it.cost
+	it.cost = cost
+
+	switch {
+	case ttl <= 0 && it.index != notQueued:
+		heap.Remove(&s.expiry, it.index)
+	case ttl > 0:
+		it.expiresAt = s.clock.Now().Add(ttl)
+		if it.index == notQueued {
+			heap.Push(&s.expiry, it)
+		} else {
+			heap.Fix(&s.expiry, it.index)
+		}
+	}
+
+	if s.policy != nil {
+		if ok {
+			s.policy.access(it)
+		} else {
+			s.policy.add(it)
+		}
+	}
+	// an updated value may be larger than the old one, and a value larger than the shard evicts itself
+	return append(evictions, s.evictOverflow(0, 0)...)
+}
+
+// get returns the value and its JSON encoding, if it is kept.
+func (s *shard) get(key string) (*entity.Order, []byte, bool) {
+	now := s.clock.Now()
+
+	if s.policy != nil {
+		// a hit updates the policy state, so it needs the write lock
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		it, ok := s.items[key]
+		if !ok || it.expired(now) {
+			return nil, nil, false
+		}
+		s.policy.access(it)
+		return it.value, it.encoded, true
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	it, ok := s.items[key]
+	if !ok || it.expired(now) {
+		return nil, nil, false
+	}
+	return it.value, it.encoded, true
+}
+
+// version returns the version of the unexpired value stored for key. It doesn't count as an access.
+func (s *shard) version(key string) (int, bool) {
+	now := s.clock.Now()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	it, ok := s.items[key]
+	if !ok || it.expired(now) {
+		return 0, false
+	}
+	return it.value.Version, true
+}
+
+// lookup returns the unexpired values indexed under value of the field. It doesn't count as an access.
+func (s *shard) lookup(field entity.LookupField, value string) []*entity.Order {
+	now := s.clock.Now()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var orders []*entity.Order
+	for key := range s.index[field][value] {
+		if it := s.items[key]; !it.expired(now) {
+			orders = append(orders, it.value)
+		}
+	}
+	return orders
+}
+
+func (s *shard) delete(key string) {
+	s.mutex.Lock()
+	if it, ok := s.items[key]; ok {
+		s.remove(it)
+	}
+	s.mutex.Unlock()
+}
+
+func (s *shard) clear() {
+	s.mutex.Lock()
+	for _, it := range s.items {
+		s.remove(it)
+	}
+	s.mutex.Unlock()
+}
+
+func (s *shard) appendKeys(keys []string) []string {
+	now := s.clock.Now()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for key, it := range s.items {
+		if !it.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (s *shard) len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.items)
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShards(t *testing.T) {
+	tests := []struct {
+		shards int
+		want   int
+	}{
+		{shards: 0, want: 1},
+		{shards: 1, want: 1},
+		{shards: 3, want: 4},
+		{shards: 16, want: 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.shards), func(t *testing.T) {
+			c := NewMemoryCache(Shards(tt.shards))
+			defer c.Close()
+
+			require.Len(t, c.shards, tt.want)
+		})
+	}
+}
+
+func TestMemoryCache_Sharded(t *testing.T) {
+	const (
+		shards = 8
+		keys   = 1000
+	)
+	clock := newFakeClock()
+	c := NewMemoryCache(WithClock(clock), CleanupInterval(time.Hour), Shards(shards), MaxEntries(keys))
+	defer c.Close()
+
+	order := fakeOrder(t)
+	for i := 0; i < keys/2; i++ {
+		require.NoError(t, c.Set(fmt.Sprintf("order-%d", i), order, time.Second))
+	}
+	for _, s := range c.shards {
+		require.NotZero(t, s.len(), "keys should spread over all shards")
+		require.Equal(t, keys/shards, s.maxEntries)
+	}
+	require.Equal(t, keys/2, c.Len())
+
+	clock.Advance(time.Minute)
+	c.evictExpired()
+	require.Zero(t, c.Len())
+}
+
+func TestShardCount(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int64
+		want       int
+	}{
+		{name: "unbounded", want: 8},
+		{name: "enough entries", maxEntries: 8, want: 8},
+		{name: "fewer entries than shards", maxEntries: 3, want: 2},
+		{name: "single entry", maxEntries: 1, want: 1},
+		{name: "enough bytes", maxBytes: 8 * minShardBytes, want: 8},
+		{name: "few bytes", maxBytes: 3 * minShardBytes, want: 2},
+		{name: "fewer bytes than a shard", maxBytes: 1024, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewMemoryCache(Shards(8), MaxEntries(tt.maxEntries), MaxBytes(tt.maxBytes))
+			defer c.Close()
+
+			require.Len(t, c.shards, tt.want)
+		})
+	}
+}
+
+func TestMemoryCache_ShardedSmallBounds(t *testing.T) {
+	order := fakeOrder(t)
+	cost := sizeOf(order)
+
+	tests := []struct {
+		name string
+		opts []Option
+		max  int
+	}{
+		{name: "bytes", opts: []Option{MaxBytes(3 * cost)}, max: 3},
+		{name: "entries", opts: []Option{MaxEntries(3)}, max: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewMemoryCache(append([]Option{CleanupInterval(time.Hour), Shards(defaultShards)}, tt.opts...)...)
+			defer c.Close()
+
+			for i := 0; i < 100; i++ {
+				require.NoError(t, c.Set(fmt.Sprintf("order-%d", i), order, time.Hour))
+			}
+			require.NotZero(t, c.Len(), "orders should fit into the cache")
+			require.LessOrEqual(t, c.Len(), tt.max)
+		})
+	}
+}
+
+const parallelKeys = 10_000
+
+// benchmarkParallel runs readers and writers in parallel, one write per writeEvery operations.
+func benchmarkParallel(b *testing.B, c *MemoryCache, writeEvery int) {
+	b.Helper()
+
+	keys := make([]string, parallelKeys)
+	order := &entity.Order{}
+	for i := range keys {
+		keys[i] = fmt.Sprintf("order-%d", i)
+		_ = c.Set(keys[i], order, time.Hour)
+	}
+
+	var seed int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(atomic.AddInt64(&seed, 1)))
+		for i := 0; pb.Next(); i++ {
+			key := keys[r.Intn(len(keys))]
+			if i%writeEvery == 0 {
+				_ = c.Set(key, order, time.Hour)
+			} else {
+				_, _ = c.Get(key)
+			}
+		}
+	})
+}
+
+func BenchmarkMemoryCache_Parallel(b *testing.B) {
+	workloads := []struct {
+		name       string
+		writeEvery int
+	}{
+		{name: "read-heavy", writeEvery: 100},
+		{name: "mixed", writeEvery: 4},
+	}
+
+	for _, workload := range workloads {
+		// a single shard behaves like the cache before sharding: one lock for everything
+		for _, shards := range []int{1, 4, defaultShards, 128} {
+			b.Run(fmt.Sprintf("%s/shards=%d", workload.name, shards), func(b *testing.B) {
+				c := NewMemoryCache(Shards(shards))
+				defer c.Close()
+
+				benchmarkParallel(b, c, workload.writeEvery)
+			})
+		}
+		b.Run(fmt.Sprintf("%s/bounded-lru/shards=%d", workload.name, defaultShards), func(b *testing.B) {
+			c := NewMemoryCache(MaxEntries(parallelKeys), EvictionPolicy(PolicyLRU))
+			defer c.Close()
+
+			benchmarkParallel(b, c, workload.writeEvery)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"unsafe"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+var (
+	orderSize        = int64(unsafe.Sizeof(entity.Order{}))
+	itemSize         = int64(unsafe.Sizeof(entity.Item{}))
+	statusChangeSize = int64(unsafe.Sizeof(entity.StatusChange{}))
+)
+
+func stringsSize(values ...string) int64 {
+	var size int64
+	for _, value := range values {
+		size += int64(len(value))
+	}
+	return size
+}
+
+// sizeOf estimates the memory held by the order: its struct and the contents of its strings and slices.
+func sizeOf(order *entity.Order) int64 {
+	if order == nil {
+		return 0
+	}
+
+	size := orderSize + stringsSize(
+		order.OrderUID,
+		order.TrackNumber,
+		order.Entry,
+		order.Locale,
+		order.InternalSignature,
+		order.CustomerID,
+		order.DeliveryService,
+		order.Shardkey,
+		order.OofShard,
+		string(order.Status),
+		order.Delivery.Name,
+		order.Delivery.Phone,
+		order.Delivery.Zip,
+		order.Delivery.City,
+		order.Delivery.Address,
+		order.Delivery.Region,
+		order.Delivery.Email,
+		order.Payment.Transaction,
+		order.Payment.RequestID,
+		string(order.Payment.Currency),
+		order.Payment.Provider,
+		order.Payment.Bank,
+	)
+
+	size += int64(cap(order.Items)) * itemSize
+	for _, item := range order.Items {
+		size += stringsSize(item.TrackNumber, item.Rid, item.Name, item.Size, item.Brand)
+	}
+
+	size += int64(cap(order.StatusHistory)) * statusChangeSize
+	for _, change := range order.StatusHistory {
+		size += stringsSize(string(change.Status), change.Reason)
+	}
+
+	return size
+}
@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+var (
+	ErrCorruptSnapshot = errors.New("cache: corrupt snapshot")
+	ErrStaleSnapshot   = errors.New("cache: stale snapshot")
+)
+
+const snapshotVersion uint16 = 1
+
+// snapshotMagic starts every snapshot file.
+var snapshotMagic = [8]byte{'W', 'B', 'L', '0', 'S', 'N', 'A', 'P'}
+
+// snapshotHeader precedes the payload. Checksum is the CRC-32 of the payload.
+type snapshotHeader struct {
+	Magic    [8]byte
+	Version  uint16
+	Length   uint64
+	Checksum uint32
+}
+
+// snapshot is the gzipped gob payload of a snapshot file.
+// Orders are kept as JSON since that is how they round-trip with their money currencies.
+type snapshot struct {
+	TakenAt time.Time
+	Entries []snapshotEntry
+}
+
+type snapshotEntry struct {
+	Key   string
+	Order []byte
+	// TTL is the time left at TakenAt, zero for entries that never expire.
+	TTL time.Duration
+}
+
+func (c *MemoryCache) takeSnapshot() (snapshot, error) {
+	now := c.shards[0].clock.Now()
+	snap := snapshot{TakenAt: now}
+
+	for _, s := range c.shards {
+		s.mutex.RLock()
+		for key, it := range s.items {
+			if it.expired(now) {
+				continue
+			}
+			order, err := json.Marshal(it.value)
+			if err != nil {
+				s.mutex.RUnlock()
+				return snapshot{}, fmt.Errorf("can not marshal order %s: %w", key, err)
+			}
+
+			entry := snapshotEntry{Key: key, Order: order}
+			if it.index != notQueued {
+				entry.TTL = it.expiresAt.Sub(now)
+			}
+			snap.Entries = append(snap.Entries, entry)
+		}
+		s.mutex.RUnlock()
+	}
+
+	return snap, nil
+}
+
+// WriteSnapshot writes the entries with their remaining ttls to w.
+func (c *MemoryCache) WriteSnapshot(w io.Writer) error {
+	snap, err := c.takeSnapshot()
+	if err != nil {
+		return err
+	}
+
+	var payload bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&payload, gzip.BestSpeed)
+	if err != nil {
+		return fmt.Errorf("can not create gzip writer: %w", err)
+	}
+	if err := gob.NewEncoder(zw).Encode(snap); err != nil {
+		return fmt.Errorf("can not encode snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("can not compress snapshot: %w", err)
+	}
+
+	header := snapshotHeader{
+		Magic:    snapshotMagic,
+		Version:  snapshotVersion,
+		Length:   uint64(payload.Len()),
+		Checksum: crc32.ChecksumIEEE(payload.Bytes()),
+	}
+	if err := binary.Write(w, binary.BigEndian, header); err != nil {
+		return fmt.Errorf("can not write snapshot header: %w", err)
+	}
+	if _, err := payload.WriteTo(w); err != nil {
+		return fmt.Errorf("can not write snapshot payload: %w", err)
+	}
+	return nil
+}
+
+// ReadSnapshot restores the entries written by WriteSnapshot and returns the time the snapshot was taken.
+// Entries keep the ttl they had left minus the time passed since then. Snapshots older than maxAge are rejected
+// with ErrStaleSnapshot, zero maxAge accepts any age. Damaged snapshots are rejected with ErrCorruptSnapshot.
+func (c *MemoryCache) ReadSnapshot(r io.Reader, maxAge time.Duration) (time.Time, error) {
+	var header snapshotHeader
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return time.Time{}, fmt.Errorf("%w: can not read header: %s", ErrCorruptSnapshot, err)
+	}
+	if header.Magic != snapshotMagic {
+		return time.Time{}, fmt.Errorf("%w: not a snapshot", ErrCorruptSnapshot)
+	}
+	if header.Version != snapshotVersion {
+		return time.Time{}, fmt.Errorf("%w: unsupported version %d", ErrCorruptSnapshot, header.Version)
+	}
+
+	var payload bytes.Buffer
+	if _, err := io.CopyN(&payload, r, int64(header.Length)); err != nil {
+		return time.Time{}, fmt.Errorf("%w: can not read payload: %s", ErrCorruptSnapshot, err)
+	}
+	if crc32.ChecksumIEEE(payload.Bytes()) != header.Checksum {
+		return time.Time{}, fmt.Errorf("%w: checksum mismatch", ErrCorruptSnapshot)
+	}
+
+	zr, err := gzip.NewReader(&payload)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: can not decompress payload: %s", ErrCorruptSnapshot, err)
+	}
+	var snap snapshot
+	if err := gob.NewDecoder(zr).Decode(&snap); err != nil {
+		return time.Time{}, fmt.Errorf("%w: can not decode payload: %s", ErrCorruptSnapshot, err)
+	}
+
+	now := c.shards[0].clock.Now()
+	age := now.Sub(snap.TakenAt)
+	if maxAge > 0 && age > maxAge {
+		return time.Time{}, fmt.Errorf("%w: taken %s ago", ErrStaleSnapshot, age)
+	}
+
+	for _, entry := range snap.Entries {
+		ttl := entry.TTL
+		if ttl > 0 {
+			if ttl -= age; ttl <= 0 {
+				continue
+			}
+		}
+
+		order := &entity.Order{}
+		if err := json.Unmarshal(entry.Order, order); err != nil {
+			return time.Time{}, fmt.Errorf("%w: can not unmarshal order %s: %s", ErrCorruptSnapshot, entry.Key, err)
+		}
+		if err := c.Set(entry.Key, order, ttl); err != nil {
+			return time.Time{}, fmt.Errorf("can not restore order %s: %w", entry.Key, err)
+		}
+	}
+
+	return snap.TakenAt, nil
+}
+
+// SaveSnapshot writes the snapshot to the file at path. The file is replaced atomically,
+// so a crash while saving leaves the previous snapshot intact.
+func (c *MemoryCache) SaveSnapshot(path string) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("can not create snapshot file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	w := bufio.NewWriter(file)
+	if err := c.WriteSnapshot(w); err != nil {
+		file.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("can not write snapshot file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("can not close snapshot file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("can not rename snapshot file: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot restores the snapshot saved by SaveSnapshot, see ReadSnapshot.
+func (c *MemoryCache) LoadSnapshot(path string, maxAge time.Duration) (time.Time, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("can not open snapshot file: %w", err)
+	}
+	defer file.Close()
+
+	return c.ReadSnapshot(bufio.NewReader(file), maxAge)
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func snapshotOrder(uid string) *entity.Order {
+	return &entity.Order{
+		OrderUID:    uid,
+		TrackNumber: "WBILMTESTTRACK",
+		Payment: entity.Payment{
+			Transaction:  uid,
+			Currency:     "USD",
+			Amount:       entity.NewMoney(1817, "USD"),
+			DeliveryCost: entity.NewMoney(1500, "USD"),
+			GoodsTotal:   entity.NewMoney(317, "USD"),
+			CustomFee:    entity.NewMoney(0, "USD"),
+		},
+		Items: []entity.Item{
+			{
+				ChrtID:      9934930,
+				TrackNumber: "WBILMTESTTRACK",
+				Price:       entity.NewMoney(453, "USD"),
+				Sale:        30,
+				TotalPrice:  entity.NewMoney(317, "USD"),
+			},
+		},
+		DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		Status:      entity.StatusCreated,
+		Version:     1,
+	}
+}
+
+func TestMemoryCache_Snapshot(t *testing.T) {
+	source, clock := newTestCache(t)
+	require.NoError(t, source.Set("expiring", snapshotOrder("expiring"), time.Minute))
+	require.NoError(t, source.Set("eternal", snapshotOrder("eternal"), 0))
+	require.NoError(t, source.Set("expired", snapshotOrder("expired"), time.Second))
+	clock.Advance(2 * time.Second)
+	takenAt := clock.Now()
+
+	var buf bytes.Buffer
+	require.NoError(t, source.WriteSnapshot(&buf))
+
+	target, targetClock := newTestCache(t)
+	targetClock.Advance(32 * time.Second)
+	gotTakenAt, err := target.ReadSnapshot(&buf, time.Hour)
+	require.NoError(t, err)
+	require.True(t, takenAt.Equal(gotTakenAt))
+	require.Equal(t, 2, target.Len())
+
+	order, err := target.Get("expiring")
+	require.NoError(t, err)
+	require.Equal(t, snapshotOrder("expiring"), order)
+	_, err = target.Get("expired")
+	require.ErrorIs(t, err, ErrItemNotFound)
+
+	// 58 seconds were left when the snapshot was taken, 30 of them passed before the restore
+	targetClock.Advance(27 * time.Second)
+	_, err = target.Get("expiring")
+	require.NoError(t, err)
+	targetClock.Advance(time.Second)
+	_, err = target.Get("expiring")
+	require.ErrorIs(t, err, ErrItemNotFound)
+	_, err = target.Get("eternal")
+	require.NoError(t, err)
+}
+
+func TestMemoryCache_ReadSnapshotErrors(t *testing.T) {
+	source, _ := newTestCache(t)
+	require.NoError(t, source.Set("order", snapshotOrder("order"), time.Minute))
+	var buf bytes.Buffer
+	require.NoError(t, source.WriteSnapshot(&buf))
+	valid := buf.Bytes()
+
+	corrupt := func(offset int) []byte {
+		data := append([]byte(nil), valid...)
+		data[offset] ^= 0xff
+		return data
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		advance time.Duration
+		err     error
+	}{
+		{name: "empty", data: nil, err: ErrCorruptSnapshot},
+		{name: "bad magic", data: corrupt(0), err: ErrCorruptSnapshot},
+		{name: "unknown version", data: corrupt(9), err: ErrCorruptSnapshot},
+		{name: "damaged payload", data: corrupt(len(valid) - 1), err: ErrCorruptSnapshot},
+		{name: "truncated", data: valid[:len(valid)-1], err: ErrCorruptSnapshot},
+		{name: "stale", data: valid, advance: 2 * time.Hour, err: ErrStaleSnapshot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, clock := newTestCache(t)
+			clock.Advance(tt.advance)
+
+			_, err := target.ReadSnapshot(bytes.NewReader(tt.data), time.Hour)
+			require.ErrorIs(t, err, tt.err)
+			require.Zero(t, target.Len())
+		})
+	}
+}
+
+func TestMemoryCache_SaveLoadSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	source, _ := newTestCache(t)
+	require.NoError(t, source.Set("order", snapshotOrder("order"), time.Minute))
+	require.NoError(t, source.SaveSnapshot(path))
+	matches, err := filepath.Glob(path + ".*.tmp")
+	require.NoError(t, err)
+	require.Empty(t, matches)
+
+	target, _ := newTestCache(t)
+	_, err = target.LoadSnapshot(path, 0)
+	require.NoError(t, err)
+	order, err := target.Get("order")
+	require.NoError(t, err)
+	require.Equal(t, snapshotOrder("order"), order)
+
+	_, err = target.LoadSnapshot(filepath.Join(t.TempDir(), "missing"), 0)
+	require.Error(t, err)
+}
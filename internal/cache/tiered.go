@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+// Tier is a single level of a TieredCache.
+type Tier interface {
+	Set(string, *entity.Order, time.Duration) error
+	Get(string) (*entity.Order, error)
+	Delete(string) error
+}
+
+type batchTier interface {
+	SetMany([]*entity.Order, time.Duration) error
+}
+
+type warmedTier interface {
+	Warmed() (bool, error)
+	MarkWarmed(time.Duration) error
+}
+
+type jsonTier interface {
+	GetJSON(string) ([]byte, int, error)
+}
+
+// TieredCache serves hot orders from a small in-process L1 in front of a shared L2.
+// Writes go through to both tiers, reads fill L1 on L2 hits.
+//
+// A replica doesn't see changes made through other replicas until its L1 entry expires,
+// so l1TTL bounds how stale a read may be.
+type TieredCache struct {
+	l1    Tier
+	l2    Tier
+	l1TTL time.Duration
+}
+
+// NewTieredCache requires a positive l1TTL, L1 entries living forever would never see changes made elsewhere.
+func NewTieredCache(l1, l2 Tier, l1TTL time.Duration) (*TieredCache, error) {
+	if l1TTL <= 0 {
+		return nil, fmt.Errorf("l1 ttl should be positive, got %s", l1TTL)
+	}
+
+	return &TieredCache{
+		l1:    l1,
+		l2:    l2,
+		l1TTL: l1TTL,
+	}, nil
+}
+
+// ttl returns the L1 ttl for an entry stored in L2 for ttl.
+func (c *TieredCache) ttl(ttl time.Duration) time.Duration {
+	if ttl > 0 && ttl < c.l1TTL {
+		return ttl
+	}
+	return c.l1TTL
+}
+
+// Set stores the value in L2 and then in L1, so a failed write doesn't leave it only in this replica.
+func (c *TieredCache) Set(key string, value *entity.Order, ttl time.Duration) error {
+	if err := c.l2.Set(key, value, ttl); err != nil {
+		return err
+	}
+	if err := c.l1.Set(key, value, c.ttl(ttl)); err != nil {
+		return fmt.Errorf("can not set order to l1: %w", err)
+	}
+	return nil
+}
+
+// SetMany stores the orders in L2 only, L1 is left to the orders actually read.
+func (c *TieredCache) SetMany(orders []*entity.Order, ttl time.Duration) error {
+	if l2, ok := c.l2.(batchTier); ok {
+		return l2.SetMany(orders, ttl)
+	}
+
+	for _, order := range orders {
+		if err := c.l2.Set(order.OrderUID, order, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Warmed reports whether L2 is already warmed up by any replica, L1 is filled by reads only.
+func (c *TieredCache) Warmed() (bool, error) {
+	if l2, ok := c.l2.(warmedTier); ok {
+		return l2.Warmed()
+	}
+	return false, nil
+}
+
+// MarkWarmed marks the warm-up of L2 as finished.
+func (c *TieredCache) MarkWarmed(ttl time.Duration) error {
+	if l2, ok := c.l2.(warmedTier); ok {
+		return l2.MarkWarmed(ttl)
+	}
+	return nil
+}
+
+// Get looks the value up in L1 and then in L2. Failing to fill L1 doesn't fail the lookup.
+func (c *TieredCache) Get(key string) (*entity.Order, error) {
+	if value, err := c.l1.Get(key); err == nil {
+		return value, nil
+	}
+
+	value, err := c.l2.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	_ = c.l1.Set(key, value, c.l1TTL)
+	return value, nil
+}
+
+// GetJSON returns the JSON encoding of the value and its version. L1 hits are served from the encoding L1 keeps.
+func (c *TieredCache) GetJSON(key string) ([]byte, int, error) {
+	if l1, ok := c.l1.(jsonTier); ok {
+		if data, version, err := l1.GetJSON(key); err == nil {
+			return data, version, nil
+		}
+	}
+
+	value, err := c.Get(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, 0, fmt.Errorf("can not encode order: %w", err)
+	}
+	return data, value.Version, nil
+}
+
+// Delete removes the value from both tiers. Other replicas may serve it from their L1 until it expires there.
+func (c *TieredCache) Delete(key string) error {
+	if err := c.l1.Delete(key); err != nil {
+		return fmt.Errorf("can not delete order from l1: %w", err)
+	}
+	return c.l2.Delete(key)
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTieredCache(t *testing.T, l1TTL time.Duration) (*TieredCache, *MemoryCache, *RedisCache, *fakeClock) {
+	t.Helper()
+
+	l1, clock := newTestCache(t)
+	l2, _ := newTestRedisCache(t)
+	c, err := NewTieredCache(l1, l2, l1TTL)
+	require.NoError(t, err)
+	return c, l1, l2, clock
+}
+
+func TestNewTieredCache(t *testing.T) {
+	l1, _ := newTestCache(t)
+	l2, _ := newTestRedisCache(t)
+
+	for _, l1TTL := range []time.Duration{0, -time.Second} {
+		_, err := NewTieredCache(l1, l2, l1TTL)
+		require.Error(t, err)
+	}
+}
+
+func TestTieredCache_Set(t *testing.T) {
+	c, l1, l2, clock := newTestTieredCache(t, time.Minute)
+
+	order := snapshotOrder("order")
+	require.NoError(t, c.Set("order", order, time.Hour))
+
+	_, err := l2.Get("order")
+	require.NoError(t, err)
+	got, err := l1.Get("order")
+	require.NoError(t, err)
+	require.Equal(t, order, got)
+
+	// l1 keeps the entry no longer than its own ttl
+	clock.Advance(time.Minute)
+	_, err = l1.Get("order")
+	require.ErrorIs(t, err, ErrItemNotFound)
+
+	got, err = c.Get("order")
+	require.NoError(t, err)
+	require.Equal(t, order, got)
+}
+
+func TestTieredCache_GetFillsL1(t *testing.T) {
+	c, l1, l2, clock := newTestTieredCache(t, time.Minute)
+
+	_, err := c.Get("order")
+	require.ErrorIs(t, err, ErrItemNotFound)
+
+	order := snapshotOrder("order")
+	require.NoError(t, l2.Set("order", order, time.Hour))
+	got, err := c.Get("order")
+	require.NoError(t, err)
+	require.Equal(t, order, got)
+
+	got, err = l1.Get("order")
+	require.NoError(t, err)
+	require.Equal(t, order, got)
+
+	// a change made through another replica shows up once the l1 entry expires
+	changed := snapshotOrder("order")
+	changed.TrackNumber = "CHANGED"
+	require.NoError(t, l2.Set("order", changed, time.Hour))
+
+	got, err = c.Get("order")
+	require.NoError(t, err)
+	require.Equal(t, order.TrackNumber, got.TrackNumber)
+
+	clock.Advance(time.Minute)
+	got, err = c.Get("order")
+	require.NoError(t, err)
+	require.Equal(t, "CHANGED", got.TrackNumber)
+}
+
+func TestTieredCache_Delete(t *testing.T) {
+	c, l1, l2, _ := newTestTieredCache(t, time.Minute)
+
+	require.NoError(t, c.Set("order", snapshotOrder("order"), time.Hour))
+	require.NoError(t, c.Delete("order"))
+
+	_, err := l1.Get("order")
+	require.ErrorIs(t, err, ErrItemNotFound)
+	_, err = l2.Get("order")
+	require.ErrorIs(t, err, ErrItemNotFound)
+}
+
+func TestTieredCache_SetMany(t *testing.T) {
+	c, l1, l2, _ := newTestTieredCache(t, time.Minute)
+
+	orders := []*entity.Order{snapshotOrder("a"), snapshotOrder("b")}
+	require.NoError(t, c.SetMany(orders, time.Hour))
+
+	require.Equal(t, 0, l1.Len())
+	for _, order := range orders {
+		_, err := l2.Get(order.OrderUID)
+		require.NoError(t, err)
+	}
+}
+
+func TestTieredCache_GetJSON(t *testing.T) {
+	c, l1, l2, _ := newTestTieredCache(t, time.Minute)
+
+	order := snapshotOrder("order")
+	want, err := json.Marshal(order)
+	require.NoError(t, err)
+	require.NoError(t, l2.Set("order", order, time.Hour))
+
+	// an l1 miss is read from l2 and fills l1
+	data, version, err := c.GetJSON("order")
+	require.NoError(t, err)
+	require.Equal(t, want, data)
+	require.Equal(t, order.Version, version)
+	require.Equal(t, 1, l1.Len())
+
+	data, _, err = c.GetJSON("order")
+	require.NoError(t, err)
+	require.Equal(t, want, data)
+
+	_, _, err = c.GetJSON("missing")
+	require.ErrorIs(t, err, ErrItemNotFound)
+}
+
+func TestTieredCache_Warmed(t *testing.T) {
+	c, _, l2, _ := newTestTieredCache(t, time.Minute)
+
+	require.NoError(t, c.MarkWarmed(time.Hour))
+	warmed, err := l2.Warmed()
+	require.NoError(t, err)
+	require.True(t, warmed)
+
+	warmed, err = c.Warmed()
+	require.NoError(t, err)
+	require.True(t, warmed)
+}
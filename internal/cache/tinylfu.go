@@ -0,0 +1,199 @@
+package cache
+
+import "container/list"
+
+type segment uint8
+
+const (
+	segmentWindow segment = iota
+	segmentProbation
+	segmentProtected
+)
+
+const (
+	windowPercent    = 1
+	protectedPercent = 80
+	percent          = 100
+)
+
+// tinyLFU is the W-TinyLFU policy. New items enter a small LRU window, items leaving the window
+// join the probation segment of the main SLRU space and are promoted to the protected segment on a hit.
+// When the cache is full the newest probation item competes with the oldest one,
+// the one seen less often according to the frequency sketch is evicted.
+type tinyLFU struct {
+	window    *list.List
+	probation *list.List
+	protected *list.List
+	sketch    *countMinSketch
+}
+
+func newTinyLFU() *tinyLFU {
+	return &tinyLFU{
+		window:    list.New(),
+		probation: list.New(),
+		protected: list.New(),
+		sketch:    newCountMinSketch(defaultSketchWidth),
+	}
+}
+
+func (p *tinyLFU) segment(s segment) *list.List {
+	switch s {
+	case segmentProbation:
+		return p.probation
+	case segmentProtected:
+		return p.protected
+	default:
+		return p.window
+	}
+}
+
+func (p *tinyLFU) move(it *item, to segment) {
+	p.segment(it.segment).Remove(it.element)
+	it.segment = to
+	it.element = p.segment(to).PushFront(it)
+}
+
+func (p *tinyLFU) len() int {
+	return p.window.Len() + p.probation.Len() + p.protected.Len()
+}
+
+func (p *tinyLFU) add(it *item) {
+	p.sketch.increment(it.key)
+	it.segment = segmentWindow
+	it.element = p.window.PushFront(it)
+
+	windowCapacity := p.len() * windowPercent / percent
+	if windowCapacity < 1 {
+		windowCapacity = 1
+	}
+	for p.window.Len() > windowCapacity {
+		p.move(p.window.Back().Value.(*item), segmentProbation)
+	}
+}
+
+func (p *tinyLFU) access(it *item) {
+	p.sketch.increment(it.key)
+
+	switch it.segment {
+	case segmentWindow:
+		p.window.MoveToFront(it.element)
+	case segmentProbation:
+		p.move(it, segmentProtected)
+		protectedCapacity := (p.probation.Len() + p.protected.Len()) * protectedPercent / percent
+		for p.protected.Len() > protectedCapacity {
+			p.move(p.protected.Back().Value.(*item), segmentProbation)
+		}
+	case segmentProtected:
+		p.protected.MoveToFront(it.element)
+	}
+}
+
+func (p *tinyLFU) remove(it *item) {
+	p.segment(it.segment).Remove(it.element)
+	it.element = nil
+}
+
+func (p *tinyLFU) victim() *item {
+	if p.probation.Len() == 0 {
+		for _, l := range []*list.List{p.window, p.protected} {
+			if back := l.Back(); back != nil {
+				return back.Value.(*item)
+			}
+		}
+		return nil
+	}
+
+	candidate := p.probation.Front().Value.(*item)
+	victim := p.probation.Back().Value.(*item)
+	if p.sketch.estimate(candidate.key) > p.sketch.estimate(victim.key) {
+		return victim
+	}
+	return candidate
+}
+
+const (
+	defaultSketchWidth = 1 << 16
+	sketchDepth        = 4
+	maxCounter         = 15
+	// resetMultiplier sets how many increments the sketch takes before all counters are halved,
+	// so that frequencies of items that are not popular anymore fade away.
+	resetMultiplier = 10
+)
+
+// countMinSketch estimates item frequencies in a fixed amount of memory.
+type countMinSketch struct {
+	rows      [sketchDepth][]uint8
+	mask      uint32
+	additions int
+	resetAt   int
+}
+
+// newCountMinSketch creates a sketch with width counters per row. The width must be a power of two.
+func newCountMinSketch(width int) *countMinSketch {
+	s := &countMinSketch{
+		mask:    uint32(width - 1),
+		resetAt: width * resetMultiplier,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *countMinSketch) indexes(key string) [sketchDepth]uint32 {
+	sum := hashKey(key)
+	h1, h2 := uint32(sum), uint32(sum>>32)
+
+	var indexes [sketchDepth]uint32
+	for i := range indexes {
+		indexes[i] = (h1 + uint32(i)*h2) & s.mask
+	}
+	return indexes
+}
+
+func (s *countMinSketch) increment(key string) {
+	for i, index := range s.indexes(key) {
+		if s.rows[i][index] < maxCounter {
+			s.rows[i][index]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.reset()
+	}
+}
+
+func (s *countMinSketch) estimate(key string) uint8 {
+	estimate := uint8(maxCounter)
+	for i, index := range s.indexes(key) {
+		if s.rows[i][index] < estimate {
+			estimate = s.rows[i][index]
+		}
+	}
+	return estimate
+}
+
+func (s *countMinSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] /= 2
+		}
+	}
+	s.additions /= 2
+}
+
+// hashKey is 64-bit FNV-1a. It is inlined instead of using hash/fnv to avoid allocating on every cache hit.
+func hashKey(key string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+
+	hash := uint64(offset64)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint64(key[i])
+		hash *= prime64
+	}
+	return hash
+}
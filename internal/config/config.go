@@ -24,6 +24,10 @@ type (
 		HTTP        HTTP
 		Postgres    Postgres
 		STAN        STAN
+		Cache       Cache
+		Redis       Redis
+		Warmup      Warmup
+		Ingest      Ingest
 		Logger      Logger
 	}
 
@@ -33,6 +37,11 @@ type (
 		MaxHeaderBytes int           `envconfig:"HTTP_MAX_HEADER_BYTES"                 default:"1"`
 		ReadTimeout    time.Duration `envconfig:"HTTP_READ_TIMEOUT"                     default:"10s"`
 		WriteTimeout   time.Duration `envconfig:"HTTP_WRITE_TIMEOUT"                    default:"10s"`
+		// DebugVars serves the expvar counters, e.g. ingested_orders and cache_evictions, on /debug/vars.
+		DebugVars bool `envconfig:"HTTP_DEBUG_VARS" default:"false"`
+		// CursorKey signs the cursors of the order listing, it must be the same for all replicas.
+		// It is required outside of the dev environment, where an empty key is replaced by a random one on startup.
+		CursorKey string `envconfig:"HTTP_CURSOR_KEY" json:"-"`
 	}
 
 	Postgres struct {
@@ -49,6 +58,55 @@ type (
 		Port      string `envconfig:"STAN_PORT"       required:"true"`
 		ClusterID string `envconfig:"STAN_CLUSTER_ID" required:"true"`
 		ClientID  string `envconfig:"STAN_CLIENT_ID"  required:"true"`
+		// StartMode is "after-warmup" to subscribe once the cache is warm or "immediate" to subscribe on startup.
+		StartMode string `envconfig:"STAN_START_MODE"                 default:"after-warmup"`
+	}
+
+	// Cache bounds are disabled when zero, Policy chooses the entries evicted from a bounded cache.
+	// Backend is "memory" for the in-process cache, "redis" for a cache shared by the replicas or "tiered"
+	// for a bounded in-process L1 in front of redis. L1TTL bounds how stale L1 reads may be, it should be positive.
+	Cache struct {
+		Backend      string        `envconfig:"CACHE_BACKEND"        default:"memory"`
+		L1TTL        time.Duration `envconfig:"CACHE_L1_TTL"         default:"5s"`
+		L1MaxEntries int           `envconfig:"CACHE_L1_MAX_ENTRIES" default:"10000"`
+		TTL          time.Duration `envconfig:"CACHE_TTL"            default:"1h"`
+		NegativeTTL  time.Duration `envconfig:"CACHE_NEGATIVE_TTL"   default:"1m"`
+		MaxEntries   int           `envconfig:"CACHE_MAX_ENTRIES"    default:"0"`
+		MaxBytes     int64         `envconfig:"CACHE_MAX_BYTES"      default:"0"`
+		Policy       string        `envconfig:"CACHE_POLICY"         default:"lru"`
+		Shards       int           `envconfig:"CACHE_SHARDS"         default:"32"`
+		// Invalidate evicts orders changed through other replicas from the in-process cache, see OrderChangesChannel.
+		Invalidate bool `envconfig:"CACHE_INVALIDATE" default:"true"`
+		// EncodeJSON keeps the encoded JSON of every cached order, so lookups skip encoding at the cost of memory.
+		EncodeJSON bool `envconfig:"CACHE_ENCODE_JSON" default:"true"`
+		// SnapshotPath is the file the cache is saved to on shutdown and restored from on startup.
+		// Empty path disables snapshots.
+		SnapshotPath   string        `envconfig:"CACHE_SNAPSHOT_PATH"`
+		SnapshotMaxAge time.Duration `envconfig:"CACHE_SNAPSHOT_MAX_AGE" default:"1h"`
+	}
+
+	// Redis is used only by the redis and tiered cache backends. Codec is "json" or "gzip".
+	// WarmedKey marks a finished warm-up, so that replicas started later skip it.
+	Redis struct {
+		Host      string        `envconfig:"REDIS_HOST"       default:"localhost"`
+		Port      string        `envconfig:"REDIS_PORT"       default:"6379"`
+		Password  string        `envconfig:"REDIS_PASSWORD"                         json:"-"`
+		DB        int           `envconfig:"REDIS_DB"         default:"0"`
+		KeyPrefix string        `envconfig:"REDIS_KEY_PREFIX" default:"wb-l0:order:"`
+		WarmedKey string        `envconfig:"REDIS_WARMED_KEY" default:"wb-l0:warmed"`
+		Codec     string        `envconfig:"REDIS_CODEC"      default:"json"`
+		Timeout   time.Duration `envconfig:"REDIS_TIMEOUT"    default:"1s"`
+	}
+
+	// Warmup selects the most recent orders loaded into the cache on startup. Zero Days or Limit disables the bound.
+	Warmup struct {
+		Days      int `envconfig:"WARMUP_DAYS"       default:"0"`
+		Limit     int `envconfig:"WARMUP_LIMIT"      default:"0"`
+		BatchSize int `envconfig:"WARMUP_BATCH_SIZE" default:"1000"`
+	}
+
+	Ingest struct {
+		DuplicatePolicy string `envconfig:"INGEST_DUPLICATE_POLICY" default:"reject"`
 	}
 
 	Logger struct {
@@ -56,7 +114,7 @@ type (
 	}
 )
 
-func (c *Config) isDev() bool {
+func (c *Config) IsDev() bool {
 	return c.Environment == dev
 }
 
@@ -76,7 +134,10 @@ func Get() *Config {
 		default:
 			log.Fatal("config environment should be test, prod or dev")
 		}
-		if instance.isDev() {
+		if instance.HTTP.CursorKey == "" && !instance.IsDev() {
+			log.Fatal("config HTTP_CURSOR_KEY is required outside of the dev environment")
+		}
+		if instance.IsDev() {
 			configBytes, err := json.MarshalIndent(instance, "", " ")
 			if err != nil {
 				log.Fatal(fmt.Errorf("error marshaling indent config: %w", err))
@@ -14,6 +14,7 @@ func TestGet(t *testing.T) {
 		environment      string
 		httpHost         string
 		httpPort         string
+		httpCursorKey    string
 		postgresHost     string
 		postgresPort     string
 		postgresDBName   string
@@ -29,6 +30,7 @@ func TestGet(t *testing.T) {
 		require.NoError(t, os.Setenv("ENVIRONMENT", env.environment))
 		require.NoError(t, os.Setenv("HTTP_HOST", env.httpHost))
 		require.NoError(t, os.Setenv("HTTP_PORT", env.httpPort))
+		require.NoError(t, os.Setenv("HTTP_CURSOR_KEY", env.httpCursorKey))
 		require.NoError(t, os.Setenv("POSTGRES_HOST", env.postgresHost))
 		require.NoError(t, os.Setenv("POSTGRES_PORT", env.postgresPort))
 		require.NoError(t, os.Setenv("POSTGRES_DBNAME", env.postgresDBName))
@@ -51,6 +53,7 @@ func TestGet(t *testing.T) {
 				environment:      "test",
 				httpHost:         "0.0.0.0",
 				httpPort:         "8080",
+				httpCursorKey:    "test-cursor-key",
 				postgresHost:     "postgres",
 				postgresPort:     "5431",
 				postgresDBName:   "test_wb-l0",
@@ -69,6 +72,8 @@ func TestGet(t *testing.T) {
 					MaxHeaderBytes: 1,
 					ReadTimeout:    10 * time.Second,
 					WriteTimeout:   10 * time.Second,
+					DebugVars:      false,
+					CursorKey:      "test-cursor-key",
 				},
 				Postgres: Postgres{
 					Host:     "postgres",
@@ -83,6 +88,34 @@ func TestGet(t *testing.T) {
 					Port:      "4222",
 					ClusterID: "test-cluster",
 					ClientID:  "test-client",
+					StartMode: "after-warmup",
+				},
+				Cache: Cache{
+					Backend:      "memory",
+					L1TTL:        5 * time.Second,
+					L1MaxEntries: 10000,
+					TTL:          time.Hour,
+					NegativeTTL:  time.Minute,
+					Policy:       "lru",
+					Shards:       32,
+					Invalidate:   true,
+					EncodeJSON:   true,
+
+					SnapshotMaxAge: time.Hour,
+				},
+				Redis: Redis{
+					Host:      "localhost",
+					Port:      "6379",
+					KeyPrefix: "wb-l0:order:",
+					WarmedKey: "wb-l0:warmed",
+					Codec:     "json",
+					Timeout:   time.Second,
+				},
+				Warmup: Warmup{
+					BatchSize: 1000,
+				},
+				Ingest: Ingest{
+					DuplicatePolicy: "reject",
 				},
 				Logger: Logger{
 					Level: "info",
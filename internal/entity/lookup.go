@@ -0,0 +1,57 @@
+package entity
+
+import "fmt"
+
+// LookupField is an order attribute orders can be looked up by besides the order uid.
+type LookupField string
+
+const (
+	LookupTrackNumber LookupField = "track_number"
+	LookupCustomerID  LookupField = "customer_id"
+	LookupTransaction LookupField = "transaction"
+	LookupRid         LookupField = "rid"
+)
+
+// LookupFields lists every field orders can be looked up by.
+var LookupFields = []LookupField{LookupTrackNumber, LookupCustomerID, LookupTransaction, LookupRid}
+
+func ParseLookupField(field string) (LookupField, error) {
+	for _, lookupField := range LookupFields {
+		if LookupField(field) == lookupField {
+			return lookupField, nil
+		}
+	}
+	return "", fmt.Errorf("unknown lookup field %q", field)
+}
+
+// Unique reports whether a value of the field identifies at most one order, i.e. the field has a uniqueness
+// constraint: orders.track_number and payments.transaction. Items of different orders may share a rid.
+func (f LookupField) Unique() bool {
+	return f == LookupTrackNumber || f == LookupTransaction
+}
+
+// LookupValues returns the values of the field in the order. Empty values are skipped.
+func (o *Order) LookupValues(field LookupField) []string {
+	var values []string
+	switch field {
+	case LookupTrackNumber:
+		values = []string{o.TrackNumber}
+	case LookupCustomerID:
+		values = []string{o.CustomerID}
+	case LookupTransaction:
+		values = []string{o.Payment.Transaction}
+	case LookupRid:
+		values = make([]string, 0, len(o.Items))
+		for _, item := range o.Items {
+			values = append(values, item.Rid)
+		}
+	}
+
+	result := values[:0]
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
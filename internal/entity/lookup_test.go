@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLookupField(t *testing.T) {
+	for _, field := range LookupFields {
+		parsed, err := ParseLookupField(string(field))
+		require.NoError(t, err)
+		require.Equal(t, field, parsed)
+	}
+
+	_, err := ParseLookupField("order_uid")
+	require.Error(t, err)
+}
+
+func TestLookupField_Unique(t *testing.T) {
+	require.True(t, LookupTrackNumber.Unique())
+	require.True(t, LookupTransaction.Unique())
+	require.False(t, LookupCustomerID.Unique())
+	require.False(t, LookupRid.Unique())
+}
+
+func TestOrder_LookupValues(t *testing.T) {
+	order := validOrder(t)
+
+	require.Equal(t, []string{order.TrackNumber}, order.LookupValues(LookupTrackNumber))
+	require.Equal(t, []string{order.CustomerID}, order.LookupValues(LookupCustomerID))
+	require.Equal(t, []string{order.Payment.Transaction}, order.LookupValues(LookupTransaction))
+	require.Equal(t, []string{order.Items[0].Rid}, order.LookupValues(LookupRid))
+
+	order.CustomerID = ""
+	require.Empty(t, order.LookupValues(LookupCustomerID))
+}
@@ -0,0 +1,153 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var ErrCurrencyMismatch = errors.New("money: currency mismatch")
+
+// Currency is an ISO 4217 alphabetic currency code.
+type Currency string
+
+const defaultExponent = 2
+
+// currencyExponents maps active ISO 4217 codes to the number of digits after the decimal separator.
+// Codes missing from the map are not valid currencies.
+var currencyExponents = map[Currency]int{
+	"AED": 2, "AFN": 2, "ALL": 2, "AMD": 2, "ANG": 2, "AOA": 2, "ARS": 2, "AUD": 2, "AWG": 2, "AZN": 2,
+	"BAM": 2, "BBD": 2, "BDT": 2, "BGN": 2, "BHD": 3, "BIF": 0, "BMD": 2, "BND": 2, "BOB": 2, "BRL": 2,
+	"BSD": 2, "BTN": 2, "BWP": 2, "BYN": 2, "BZD": 2, "CAD": 2, "CDF": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"COP": 2, "CRC": 2, "CUP": 2, "CVE": 2, "CZK": 2, "DJF": 0, "DKK": 2, "DOP": 2, "DZD": 2, "EGP": 2,
+	"ERN": 2, "ETB": 2, "EUR": 2, "FJD": 2, "FKP": 2, "GBP": 2, "GEL": 2, "GHS": 2, "GIP": 2, "GMD": 2,
+	"GNF": 0, "GTQ": 2, "GYD": 2, "HKD": 2, "HNL": 2, "HTG": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2,
+	"IQD": 3, "IRR": 2, "ISK": 0, "JMD": 2, "JOD": 3, "JPY": 0, "KES": 2, "KGS": 2, "KHR": 2, "KMF": 0,
+	"KPW": 2, "KRW": 0, "KWD": 3, "KYD": 2, "KZT": 2, "LAK": 2, "LBP": 2, "LKR": 2, "LRD": 2, "LSL": 2,
+	"LYD": 3, "MAD": 2, "MDL": 2, "MGA": 2, "MKD": 2, "MMK": 2, "MNT": 2, "MOP": 2, "MRU": 2, "MUR": 2,
+	"MVR": 2, "MWK": 2, "MXN": 2, "MYR": 2, "MZN": 2, "NAD": 2, "NGN": 2, "NIO": 2, "NOK": 2, "NPR": 2,
+	"NZD": 2, "OMR": 3, "PAB": 2, "PEN": 2, "PGK": 2, "PHP": 2, "PKR": 2, "PLN": 2, "PYG": 0, "QAR": 2,
+	"RON": 2, "RSD": 2, "RUB": 2, "RWF": 0, "SAR": 2, "SBD": 2, "SCR": 2, "SDG": 2, "SEK": 2, "SGD": 2,
+	"SHP": 2, "SLE": 2, "SOS": 2, "SRD": 2, "SSP": 2, "STN": 2, "SYP": 2, "SZL": 2, "THB": 2, "TJS": 2,
+	"TMT": 2, "TND": 3, "TOP": 2, "TRY": 2, "TTD": 2, "TWD": 2, "TZS": 2, "UAH": 2, "UGX": 0, "USD": 2,
+	"UYU": 2, "UZS": 2, "VES": 2, "VND": 0, "VUV": 0, "WST": 2, "XAF": 0, "XCD": 2, "XOF": 0, "XPF": 0,
+	"YER": 2, "ZAR": 2, "ZMW": 2, "ZWL": 2,
+}
+
+func (c Currency) Valid() bool {
+	_, ok := currencyExponents[c]
+	return ok
+}
+
+// Exponent returns the number of minor unit digits of the currency.
+// Unknown currencies are treated as having two of them.
+func (c Currency) Exponent() int {
+	if exponent, ok := currencyExponents[c]; ok {
+		return exponent
+	}
+	return defaultExponent
+}
+
+// Money is an amount of minor units (e.g. cents) in a currency.
+//
+// On the wire Money is encoded as a bare integer of minor units, the currency is taken from Payment.Currency.
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+func NewMoney(amount int64, currency Currency) Money {
+	return Money{amount: amount, currency: currency}
+}
+
+func (m Money) MinorUnits() int64 {
+	return m.amount
+}
+
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.currency != other.currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	return NewMoney(m.amount+other.amount, m.currency), nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if m.currency != other.currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, other.currency)
+	}
+	return NewMoney(m.amount-other.amount, m.currency), nil
+}
+
+// ApplySale returns the amount left after a discount of percent, rounded half away from zero to a minor unit.
+func (m Money) ApplySale(percent int) Money {
+	const hundred = 100
+	return NewMoney(divRound(m.amount*int64(hundred-percent), hundred), m.currency)
+}
+
+// Round rounds the amount half away from zero to the given number of digits after the decimal separator.
+func (m Money) Round(digits int) Money {
+	exponent := m.currency.Exponent()
+	if digits >= exponent {
+		return m
+	}
+	if digits < 0 {
+		digits = 0
+	}
+
+	factor := int64(1)
+	for i := digits; i < exponent; i++ {
+		factor *= 10
+	}
+	return NewMoney(divRound(m.amount, factor)*factor, m.currency)
+}
+
+func (m Money) String() string {
+	exponent := m.currency.Exponent()
+	if exponent == 0 {
+		return fmt.Sprintf("%d %s", m.amount, m.currency)
+	}
+
+	factor := int64(1)
+	for i := 0; i < exponent; i++ {
+		factor *= 10
+	}
+	sign := ""
+	amount := m.amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/factor, exponent, amount%factor, m.currency)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, m.amount, 10), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	amount, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("money: amount must be an integer number of minor units: %w", err)
+	}
+	m.amount = amount
+	return nil
+}
+
+// divRound divides n by positive d rounding half away from zero.
+func divRound(n, d int64) int64 {
+	if n < 0 {
+		return -((-n + d/2) / d)
+	}
+	return (n + d/2) / d
+}
@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoney_Add(t *testing.T) {
+	sum, err := NewMoney(1500, "USD").Add(NewMoney(317, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(1817, "USD"), sum)
+
+	_, err = NewMoney(1500, "USD").Add(NewMoney(317, "RUB"))
+	require.True(t, errors.Is(err, ErrCurrencyMismatch))
+}
+
+func TestMoney_Sub(t *testing.T) {
+	diff, err := NewMoney(317, "USD").Sub(NewMoney(1500, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(-1183, "USD"), diff)
+
+	_, err = NewMoney(317, "USD").Sub(NewMoney(1500, "EUR"))
+	require.True(t, errors.Is(err, ErrCurrencyMismatch))
+}
+
+func TestMoney_ApplySale(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		percent int
+		want    Money
+	}{
+		{name: "round down", money: NewMoney(453, "USD"), percent: 30, want: NewMoney(317, "USD")},
+		{name: "round half up", money: NewMoney(5, "USD"), percent: 50, want: NewMoney(3, "USD")},
+		{name: "negative", money: NewMoney(-5, "USD"), percent: 50, want: NewMoney(-3, "USD")},
+		{name: "no sale", money: NewMoney(453, "USD"), percent: 0, want: NewMoney(453, "USD")},
+		{name: "full sale", money: NewMoney(453, "USD"), percent: 100, want: NewMoney(0, "USD")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.money.ApplySale(tt.percent))
+		})
+	}
+}
+
+func TestMoney_Round(t *testing.T) {
+	require.Equal(t, NewMoney(1800, "USD"), NewMoney(1750, "USD").Round(0))
+	require.Equal(t, NewMoney(1700, "USD"), NewMoney(1749, "USD").Round(0))
+	require.Equal(t, NewMoney(1750, "USD"), NewMoney(1750, "USD").Round(2))
+	require.Equal(t, NewMoney(1235, "JPY"), NewMoney(1235, "JPY").Round(0))
+	require.Equal(t, NewMoney(1240, "KWD"), NewMoney(1235, "KWD").Round(2))
+}
+
+func TestMoney_String(t *testing.T) {
+	require.Equal(t, "18.17 USD", NewMoney(1817, "USD").String())
+	require.Equal(t, "-0.05 USD", NewMoney(-5, "USD").String())
+	require.Equal(t, "1817 JPY", NewMoney(1817, "JPY").String())
+	require.Equal(t, "1.817 KWD", NewMoney(1817, "KWD").String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(1817, "USD"))
+	require.NoError(t, err)
+	require.Equal(t, "1817", string(data))
+
+	var money Money
+	require.NoError(t, json.Unmarshal([]byte("1817"), &money))
+	require.Equal(t, int64(1817), money.MinorUnits())
+
+	require.Error(t, json.Unmarshal([]byte("18.17"), &money))
+	require.Error(t, json.Unmarshal([]byte(`"1817"`), &money))
+}
+
+func TestOrder_UnmarshalJSON(t *testing.T) {
+	order := validOrder(t)
+	data, err := json.Marshal(order)
+	require.NoError(t, err)
+
+	got := &Order{}
+	require.NoError(t, json.Unmarshal(data, got))
+	require.Equal(t, order, got)
+	require.Equal(t, Currency("USD"), got.Items[0].Price.Currency())
+}
@@ -1,24 +1,30 @@
 package entity
 
 import (
+	"encoding/json"
+	"reflect"
 	"time"
 )
 
 type Order struct {
-	OrderUID          string    `json:"order_uid"`
-	TrackNumber       string    `json:"track_number"`
-	Entry             string    `json:"entry"`
-	Delivery          Delivery  `json:"delivery"`
-	Payment           Payment   `json:"payment"`
-	Items             []Item    `json:"items"`
-	Locale            string    `json:"locale"`
-	InternalSignature string    `json:"internal_signature"`
-	CustomerID        string    `json:"customer_id"`
-	DeliveryService   string    `json:"delivery_service"`
-	Shardkey          string    `json:"shardkey"`
-	SmID              int       `json:"sm_id"`
-	DateCreated       time.Time `json:"date_created"`
-	OofShard          string    `json:"oof_shard"`
+	OrderUID          string         `json:"order_uid"`
+	TrackNumber       string         `json:"track_number"`
+	Entry             string         `json:"entry"`
+	Delivery          Delivery       `json:"delivery"`
+	Payment           Payment        `json:"payment"`
+	Items             []Item         `json:"items"`
+	Locale            string         `json:"locale"`
+	InternalSignature string         `json:"internal_signature"`
+	CustomerID        string         `json:"customer_id"`
+	DeliveryService   string         `json:"delivery_service"`
+	Shardkey          string         `json:"shardkey"`
+	SmID              int            `json:"sm_id"`
+	DateCreated       time.Time      `json:"date_created"`
+	OofShard          string         `json:"oof_shard"`
+	Status            OrderStatus    `json:"status"`
+	StatusHistory     []StatusChange `json:"status_history"`
+	Version           int            `json:"version"`
+	CancelledAt       *time.Time     `json:"cancelled_at,omitempty"`
 }
 
 type Delivery struct {
@@ -32,28 +38,95 @@ type Delivery struct {
 }
 
 type Payment struct {
-	Transaction  string `json:"transaction"`
-	RequestID    string `json:"request_id"`
-	Currency     string `json:"currency"`
-	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
-	PaymentDt    int    `json:"payment_dt"`
-	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
+	Transaction  string   `json:"transaction"`
+	RequestID    string   `json:"request_id"`
+	Currency     Currency `json:"currency"`
+	Provider     string   `json:"provider"`
+	Amount       Money    `json:"amount"`
+	PaymentDt    int      `json:"payment_dt"`
+	Bank         string   `json:"bank"`
+	DeliveryCost Money    `json:"delivery_cost"`
+	GoodsTotal   Money    `json:"goods_total"`
+	CustomFee    Money    `json:"custom_fee"`
 }
 
 type Item struct {
 	ChrtID      int    `json:"chrt_id"`
 	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price"`
+	Price       Money  `json:"price"`
 	Rid         string `json:"rid"`
 	Name        string `json:"name"`
 	Sale        int    `json:"sale"`
 	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
+	TotalPrice  Money  `json:"total_price"`
 	NmID        int    `json:"nm_id"`
 	Brand       string `json:"brand"`
 	Status      int    `json:"status"`
 }
+
+func (o *Order) UnmarshalJSON(data []byte) error {
+	type order Order
+	if err := json.Unmarshal(data, (*order)(o)); err != nil {
+		return err
+	}
+	o.SetCurrency(o.Payment.Currency)
+	return nil
+}
+
+// SetCurrency sets the currency of the payment and binds every monetary amount of the order to it.
+func (o *Order) SetCurrency(currency Currency) {
+	o.Payment.Currency = currency
+	o.Payment.Amount = NewMoney(o.Payment.Amount.MinorUnits(), currency)
+	o.Payment.DeliveryCost = NewMoney(o.Payment.DeliveryCost.MinorUnits(), currency)
+	o.Payment.GoodsTotal = NewMoney(o.Payment.GoodsTotal.MinorUnits(), currency)
+	o.Payment.CustomFee = NewMoney(o.Payment.CustomFee.MinorUnits(), currency)
+	for i := range o.Items {
+		o.Items[i].Price = NewMoney(o.Items[i].Price.MinorUnits(), currency)
+		o.Items[i].TotalPrice = NewMoney(o.Items[i].TotalPrice.MinorUnits(), currency)
+	}
+}
+
+// Clone returns a deep copy of the order sharing no memory with it.
+func (o *Order) Clone() *Order {
+	if o == nil {
+		return nil
+	}
+
+	clone := *o
+	if o.Items != nil {
+		clone.Items = make([]Item, len(o.Items))
+		copy(clone.Items, o.Items)
+	}
+	if o.StatusHistory != nil {
+		clone.StatusHistory = make([]StatusChange, len(o.StatusHistory))
+		copy(clone.StatusHistory, o.StatusHistory)
+	}
+	if o.CancelledAt != nil {
+		cancelledAt := *o.CancelledAt
+		clone.CancelledAt = &cancelledAt
+	}
+	return &clone
+}
+
+// EqualContent reports whether both orders carry the same ingested data.
+// Fields managed by the service, like the status, are not compared.
+func (o *Order) EqualContent(other *Order) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+	return reflect.DeepEqual(o.content(), other.content())
+}
+
+func (o *Order) content() Order {
+	content := *o
+	content.Status = ""
+	content.StatusHistory = nil
+	content.Version = 0
+	content.CancelledAt = nil
+	// postgres keeps timestamps with microsecond precision
+	content.DateCreated = content.DateCreated.UTC().Truncate(time.Microsecond)
+	if len(content.Items) == 0 {
+		content.Items = nil
+	}
+	return content
+}
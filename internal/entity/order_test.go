@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrder_EqualContent(t *testing.T) {
+	order := validOrder(t)
+
+	stored := validOrder(t)
+	stored.Status = StatusPaid
+	stored.StatusHistory = []StatusChange{{Status: StatusPaid}}
+	stored.DateCreated = order.DateCreated.In(time.FixedZone("MSK", 3*60*60))
+	require.True(t, order.EqualContent(stored))
+
+	changed := validOrder(t)
+	changed.Delivery.Address = "Other street"
+	require.False(t, order.EqualContent(changed))
+
+	require.False(t, order.EqualContent(nil))
+}
+
+func TestOrder_Clone(t *testing.T) {
+	order := validOrder(t)
+	cancelledAt := time.Now()
+	order.CancelledAt = &cancelledAt
+	order.StatusHistory = []StatusChange{{Status: StatusCreated}}
+
+	clone := order.Clone()
+	require.Equal(t, order, clone)
+
+	clone.Items[0].Name = "changed"
+	clone.StatusHistory[0].Status = StatusPaid
+	*clone.CancelledAt = cancelledAt.Add(time.Hour)
+	require.NotEqual(t, clone.Items[0].Name, order.Items[0].Name)
+	require.Equal(t, StatusCreated, order.StatusHistory[0].Status)
+	require.Equal(t, cancelledAt, *order.CancelledAt)
+
+	require.Nil(t, (*Order)(nil).Clone())
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+type OrderStatus string
+
+const (
+	StatusCreated   OrderStatus = "created"
+	StatusPaid      OrderStatus = "paid"
+	StatusAssembled OrderStatus = "assembled"
+	StatusShipped   OrderStatus = "shipped"
+	StatusDelivered OrderStatus = "delivered"
+	StatusCancelled OrderStatus = "cancelled"
+	StatusReturned  OrderStatus = "returned"
+)
+
+// StatusChange is an entry of the order status history.
+type StatusChange struct {
+	Status    OrderStatus `json:"status"`
+	Reason    string      `json:"reason"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
@@ -0,0 +1,142 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+const maxSale = 100
+
+// FieldError describes a single violated constraint. Field is the JSON path of the offending value.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (fe FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", fe.Field, fe.Message)
+}
+
+// ValidationError collects every constraint violated by an order.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (ve *ValidationError) Error() string {
+	messages := make([]string, 0, len(ve.Fields))
+	for _, field := range ve.Fields {
+		messages = append(messages, field.Error())
+	}
+	return "invalid order: " + strings.Join(messages, "; ")
+}
+
+func (ve *ValidationError) add(field, format string, args ...interface{}) {
+	ve.Fields = append(ve.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (ve *ValidationError) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		ve.add(field, "is required")
+	}
+}
+
+func (ve *ValidationError) nonNegative(field string, value int) {
+	if value < 0 {
+		ve.add(field, "must be non-negative, got %d", value)
+	}
+}
+
+func (ve *ValidationError) nonNegativeMoney(field string, value Money) {
+	if value.IsNegative() {
+		ve.add(field, "must be non-negative, got %d", value.MinorUnits())
+	}
+}
+
+// Validate checks the order for missing fields and inconsistent values.
+// It returns *ValidationError listing all violations or nil if the order is valid.
+func (o *Order) Validate() error {
+	ve := &ValidationError{}
+	if o == nil {
+		ve.add("order", "is required")
+		return ve
+	}
+
+	ve.required("order_uid", o.OrderUID)
+	ve.required("track_number", o.TrackNumber)
+	ve.required("entry", o.Entry)
+	ve.required("customer_id", o.CustomerID)
+	ve.required("delivery_service", o.DeliveryService)
+	if o.DateCreated.IsZero() {
+		ve.add("date_created", "is required")
+	}
+	if _, err := language.Parse(o.Locale); err != nil {
+		ve.add("locale", "invalid locale code %q", o.Locale)
+	}
+
+	o.Delivery.validate(ve)
+	o.Payment.validate(ve)
+
+	if len(o.Items) == 0 {
+		ve.add("items", "must contain at least one item")
+	}
+	goodsTotal := NewMoney(0, o.Payment.Currency)
+	for i, item := range o.Items {
+		path := fmt.Sprintf("items[%d]", i)
+		item.validate(ve, path, o.TrackNumber)
+
+		total, err := goodsTotal.Add(item.TotalPrice)
+		if err != nil {
+			ve.add(path+".total_price", "must be in payment currency: %s", err)
+			continue
+		}
+		goodsTotal = total
+	}
+	if goodsTotal != o.Payment.GoodsTotal {
+		ve.add(
+			"payment.goods_total",
+			"must equal the sum of items total_price %d, got %d",
+			goodsTotal.MinorUnits(),
+			o.Payment.GoodsTotal.MinorUnits(),
+		)
+	}
+
+	if len(ve.Fields) > 0 {
+		return ve
+	}
+	return nil
+}
+
+func (d Delivery) validate(ve *ValidationError) {
+	ve.required("delivery.name", d.Name)
+	ve.required("delivery.phone", d.Phone)
+	ve.required("delivery.city", d.City)
+	ve.required("delivery.address", d.Address)
+}
+
+func (p Payment) validate(ve *ValidationError) {
+	ve.required("payment.transaction", p.Transaction)
+	if !p.Currency.Valid() {
+		ve.add("payment.currency", "invalid ISO 4217 currency code %q", p.Currency)
+	}
+	ve.required("payment.provider", p.Provider)
+	ve.nonNegativeMoney("payment.amount", p.Amount)
+	ve.nonNegative("payment.payment_dt", p.PaymentDt)
+	ve.nonNegativeMoney("payment.delivery_cost", p.DeliveryCost)
+	ve.nonNegativeMoney("payment.goods_total", p.GoodsTotal)
+	ve.nonNegativeMoney("payment.custom_fee", p.CustomFee)
+}
+
+func (i Item) validate(ve *ValidationError, path, trackNumber string) {
+	ve.required(path+".rid", i.Rid)
+	ve.required(path+".name", i.Name)
+	if i.TrackNumber != trackNumber {
+		ve.add(path+".track_number", "must equal order track_number %q, got %q", trackNumber, i.TrackNumber)
+	}
+	ve.nonNegativeMoney(path+".price", i.Price)
+	ve.nonNegativeMoney(path+".total_price", i.TotalPrice)
+	if i.Sale < 0 || i.Sale > maxSale {
+		ve.add(path+".sale", "must be between 0 and %d, got %d", maxSale, i.Sale)
+	}
+}
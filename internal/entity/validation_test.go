@@ -0,0 +1,162 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func validOrder(t *testing.T) *Order {
+	t.Helper()
+
+	return &Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: Payment{
+			Transaction:  "b563feb7b2b84b6test",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       NewMoney(1817, "USD"),
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: NewMoney(1500, "USD"),
+			GoodsTotal:   NewMoney(317, "USD"),
+			CustomFee:    NewMoney(0, "USD"),
+		},
+		Items: []Item{
+			{
+				ChrtID:      9934930,
+				TrackNumber: "WBILMTESTTRACK",
+				Price:       NewMoney(453, "USD"),
+				Rid:         "ab4219087a764ae0btest",
+				Name:        "Mascaras",
+				Sale:        30,
+				Size:        "0",
+				TotalPrice:  NewMoney(317, "USD"),
+				NmID:        2389212,
+				Brand:       "Vivienne Sabo",
+				Status:      202,
+			},
+		},
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmID:            99,
+		DateCreated:     time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		OofShard:        "1",
+	}
+}
+
+func TestOrder_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Order)
+		fields []string
+	}{
+		{
+			name:   "valid order",
+			modify: func(*Order) {},
+			fields: nil,
+		},
+		{
+			name: "missing required fields",
+			modify: func(o *Order) {
+				o.Entry = ""
+				o.CustomerID = " "
+				o.Delivery.Phone = ""
+			},
+			fields: []string{"entry", "customer_id", "delivery.phone"},
+		},
+		{
+			name: "transaction differs from order uid",
+			modify: func(o *Order) {
+				o.Payment.Transaction = "other"
+			},
+			fields: nil,
+		},
+		{
+			name: "item track number mismatch",
+			modify: func(o *Order) {
+				o.Items[0].TrackNumber = "OTHER"
+			},
+			fields: []string{"items[0].track_number"},
+		},
+		{
+			name: "negative amounts",
+			modify: func(o *Order) {
+				o.Payment.DeliveryCost = NewMoney(-1, "USD")
+				o.Payment.CustomFee = NewMoney(-5, "USD")
+			},
+			fields: []string{"payment.delivery_cost", "payment.custom_fee"},
+		},
+		{
+			name: "invalid currency and locale",
+			modify: func(o *Order) {
+				o.SetCurrency("usd")
+				o.Locale = "not a locale"
+			},
+			fields: []string{"locale", "payment.currency"},
+		},
+		{
+			name: "item in another currency",
+			modify: func(o *Order) {
+				o.Items[0].TotalPrice = NewMoney(317, "EUR")
+			},
+			fields: []string{"items[0].total_price", "payment.goods_total"},
+		},
+		{
+			name: "inconsistent goods total",
+			modify: func(o *Order) {
+				o.Items = append(o.Items, o.Items[0])
+			},
+			fields: []string{"payment.goods_total"},
+		},
+		{
+			name: "no items",
+			modify: func(o *Order) {
+				o.Items = nil
+				o.Payment.GoodsTotal = NewMoney(0, "USD")
+			},
+			fields: []string{"items"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := validOrder(t)
+			tt.modify(order)
+
+			err := order.Validate()
+			if tt.fields == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			fields := make([]string, 0, len(validationErr.Fields))
+			for _, field := range validationErr.Fields {
+				fields = append(fields, field.Field)
+			}
+			require.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestOrder_ValidateNil(t *testing.T) {
+	var order *Order
+	require.Error(t, order.Validate())
+}
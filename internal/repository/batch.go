@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+)
+
+// statement is a query of a batch. The name shows up in the errors.
+type statement struct {
+	name  string
+	query sq.Sqlizer
+}
+
+// execBatch sends the statements in a single round trip. They run in order and the first failure stops the batch.
+func execBatch(ctx context.Context, tx pgx.Tx, statements []statement) error {
+	batch := &pgx.Batch{}
+	for _, stmt := range statements {
+		sql, args, err := stmt.query.ToSql()
+		if err != nil {
+			return fmt.Errorf("can not build %s query: %w", stmt.name, err)
+		}
+		batch.Queue(sql, args...)
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	for _, stmt := range statements {
+		if _, err := results.Exec(); err != nil {
+			results.Close()
+			return fmt.Errorf("can not %s: %w", stmt.name, translateError(err))
+		}
+	}
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("can not close batch: %w", translateError(err))
+	}
+	return nil
+}
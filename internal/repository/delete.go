@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/maypok86/wb-l0/internal/usecase"
+)
+
+// DeleteOrder marks the order as deleted. Deleted orders are no longer returned by the repository.
+func (opr OrderPostgresRepository) DeleteOrder(ctx context.Context, orderUID string) error {
+	tx, err := opr.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can not begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback(ctx)
+
+	sql, args, err := opr.db.Builder.Update("orders").
+		Set("deleted_at", sq.Expr("now()")).
+		Set("changed_at", sq.Expr("now()")).
+		Where("order_uid = ? AND deleted_at IS NULL", orderUID).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("can not build delete order query: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("can not delete order: %w", translateError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return usecase.NewError(usecase.ErrNotFound, fmt.Errorf("can not delete order: order %s not found", orderUID))
+	}
+
+	if err := opr.notifyOrderChanged(ctx, tx, orderUID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can not commit transaction: %w", translateError(err))
+	}
+
+	return nil
+}
+
+// PurgeOrder removes the order with its items, delivery, payment, status history and flagged duplicates.
+// Soft-deleted orders can be purged too.
+func (opr OrderPostgresRepository) PurgeOrder(ctx context.Context, orderUID string) error {
+	tx, err := opr.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can not begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback(ctx)
+
+	sql, args, err := opr.db.Builder.Select(
+		"delivery_id",
+	).From("orders").Where("order_uid = ?", orderUID).Suffix("FOR UPDATE").ToSql()
+	if err != nil {
+		return fmt.Errorf("can not build lock order query: %w", err)
+	}
+
+	var locked lockedOrder
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&locked.deliveryID); err != nil {
+		return fmt.Errorf("can not lock order: %w", translateError(err))
+	}
+
+	// Rows are removed in foreign key order: everything referencing the order goes first,
+	// then the order itself and then its delivery. Items and payment are deleted along with the order.
+	if err := opr.deleteWhere(ctx, tx, "order_status_history", "order_uid", orderUID); err != nil {
+		return err
+	}
+	if err := opr.deleteWhere(ctx, tx, "order_duplicates", "order_uid", orderUID); err != nil {
+		return err
+	}
+	if err := opr.deleteWhere(ctx, tx, "orders", "order_uid", orderUID); err != nil {
+		return err
+	}
+	if err := opr.deleteWhere(ctx, tx, "deliveries", "id", locked.deliveryID); err != nil {
+		return err
+	}
+
+	if err := opr.notifyOrderChanged(ctx, tx, orderUID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can not commit transaction: %w", translateError(err))
+	}
+
+	return nil
+}
+
+func (opr OrderPostgresRepository) deleteWhere(
+	ctx context.Context,
+	tx pgx.Tx,
+	table string,
+	column string,
+	value interface{},
+) error {
+	sql, args, err := opr.db.Builder.Delete(table).Where(column+" = ?", value).ToSql()
+	if err != nil {
+		return fmt.Errorf("can not build delete %s query: %w", table, err)
+	}
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("can not delete %s: %w", table, translateError(err))
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+// OverwriteOrder replaces delivery, payment, items and the ingested fields of a stored order in one transaction.
+// The status and its history are kept.
+func (opr OrderPostgresRepository) OverwriteOrder(ctx context.Context, order *entity.Order) (*entity.Order, error) {
+	tx, err := opr.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can not begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback(ctx)
+
+	locked, err := opr.lockOrder(ctx, tx, order.OrderUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := opr.replaceOrder(ctx, tx, order, locked); err != nil {
+		return nil, err
+	}
+
+	if err := opr.notifyOrderChanged(ctx, tx, order.OrderUID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("can not commit transaction: %w", translateError(err))
+	}
+
+	return opr.GetOrderByID(ctx, order.OrderUID)
+}
+
+// FlagDuplicate records a redelivered order which differs from the stored one for later review.
+func (opr OrderPostgresRepository) FlagDuplicate(ctx context.Context, order *entity.Order) error {
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("can not marshal duplicate order: %w", err)
+	}
+
+	sql, args, err := opr.db.Builder.Insert("order_duplicates").Columns(
+		"order_uid",
+		"payload",
+	).Values(
+		order.OrderUID,
+		string(payload),
+	).ToSql()
+	if err != nil {
+		return fmt.Errorf("can not build insert duplicate order query: %w", err)
+	}
+
+	_, err = opr.db.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("can not insert duplicate order: %w", translateError(err))
+	}
+	return nil
+}
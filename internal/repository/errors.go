@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/maypok86/wb-l0/internal/usecase"
+)
+
+// errExponentMismatch is returned for a payment whose amounts were stored with a currency exponent
+// other than the current one, reading them would scale the amounts wrong.
+var errExponentMismatch = errors.New("currency exponent mismatch")
+
+const (
+	uniqueViolation      = "23505"
+	foreignKeyViolation  = "23503"
+	notNullViolation     = "23502"
+	checkViolation       = "23514"
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	lockNotAvailable     = "55P03"
+
+	dataExceptionClass         = "22"
+	connectionExceptionClass   = "08"
+	insufficientResourcesClass = "53"
+	operatorInterventionClass  = "57"
+)
+
+// translateError tags pgx and pgconn errors with the matching usecase domain error kind.
+// Errors without a domain meaning are returned as is.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return usecase.NewError(usecase.ErrNotFound, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == uniqueViolation:
+			return usecase.NewError(usecase.ErrAlreadyExists, err)
+		case pgErr.Code == foreignKeyViolation,
+			pgErr.Code == serializationFailure,
+			pgErr.Code == deadlockDetected,
+			pgErr.Code == lockNotAvailable:
+			return usecase.NewError(usecase.ErrConflict, err)
+		case pgErr.Code == notNullViolation,
+			pgErr.Code == checkViolation,
+			strings.HasPrefix(pgErr.Code, dataExceptionClass):
+			return usecase.NewError(usecase.ErrInvalid, err)
+		case strings.HasPrefix(pgErr.Code, connectionExceptionClass),
+			strings.HasPrefix(pgErr.Code, insufficientResourcesClass),
+			strings.HasPrefix(pgErr.Code, operatorInterventionClass):
+			return usecase.NewError(usecase.ErrUnavailable, err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if pgconn.Timeout(err) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &netErr) ||
+		pgconn.SafeToRetry(err) {
+		return usecase.NewError(usecase.ErrUnavailable, err)
+	}
+
+	return err
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+)
+
+func filterCondition(filter usecase.OrderFilter) sq.And {
+	condition := sq.And{}
+
+	equal := sq.Eq{}
+	if filter.CustomerID != "" {
+		equal["orders.customer_id"] = filter.CustomerID
+	}
+	if filter.DeliveryService != "" {
+		equal["orders.delivery_service"] = filter.DeliveryService
+	}
+	if filter.Entry != "" {
+		equal["orders.entry"] = filter.Entry
+	}
+	if filter.Locale != "" {
+		equal["orders.locale"] = filter.Locale
+	}
+	if filter.Provider != "" {
+		equal["payments.provider"] = filter.Provider
+	}
+	if filter.Currency != "" {
+		equal["payments.currency"] = filter.Currency
+	}
+	if len(equal) > 0 {
+		condition = append(condition, equal)
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		condition = append(condition, sq.GtOrEq{"orders.date_created": filter.CreatedFrom})
+	}
+	if !filter.CreatedTo.IsZero() {
+		condition = append(condition, sq.Lt{"orders.date_created": filter.CreatedTo})
+	}
+	if filter.MinAmount != nil {
+		condition = append(condition, sq.GtOrEq{"payments.amount": *filter.MinAmount})
+	}
+	if filter.MaxAmount != nil {
+		condition = append(condition, sq.LtOrEq{"payments.amount": *filter.MaxAmount})
+	}
+	if filter.Brand != "" {
+		condition = append(condition, sq.Expr(
+			"orders.order_uid IN (SELECT order_uid FROM items WHERE brand = ?)",
+			filter.Brand,
+		))
+	}
+
+	return condition
+}
+
+// ListOrders returns a page of the orders matching the filter, newest first. Pages are keyset-paginated
+// by (date_created, order_uid) like GetOrdersBatch.
+func (opr OrderPostgresRepository) ListOrders(
+	ctx context.Context,
+	filter usecase.OrderFilter,
+	page usecase.OrderPage,
+) ([]*entity.Order, error) {
+	return opr.selectOrders(ctx, opr.listOrdersQuery(filter, page.After), page.Limit)
+}
+
+func (opr OrderPostgresRepository) listOrdersQuery(filter usecase.OrderFilter, after *usecase.OrderCursor) sq.SelectBuilder {
+	query := opr.ordersQuery()
+	if condition := filterCondition(filter); len(condition) > 0 {
+		query = query.Where(condition)
+	}
+	if after != nil {
+		query = query.Where(
+			"(orders.date_created, orders.order_uid) < (?, ?)",
+			after.DateCreated,
+			after.OrderUID,
+		)
+	}
+	return query
+}
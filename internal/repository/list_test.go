@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderPostgresRepository_listOrdersQuery(t *testing.T) {
+	repo := newTestRepository()
+	minAmount, maxAmount := int64(100), int64(5000)
+	from := time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	after := &usecase.OrderCursor{DateCreated: from, OrderUID: "uid"}
+
+	sql, args, err := repo.listOrdersQuery(usecase.OrderFilter{
+		CustomerID:  "test",
+		Provider:    "wbpay",
+		CreatedFrom: from,
+		MinAmount:   &minAmount,
+		MaxAmount:   &maxAmount,
+		Brand:       "Vivienne Sabo",
+	}, after).ToSql()
+	require.NoError(t, err)
+
+	require.Contains(t, sql, "WHERE orders.deleted_at IS NULL AND (orders.customer_id = $1 AND payments.provider = $2 "+
+		"AND orders.date_created >= $3 AND payments.amount >= $4 AND payments.amount <= $5 "+
+		"AND orders.order_uid IN (SELECT order_uid FROM items WHERE brand = $6)) "+
+		"AND (orders.date_created, orders.order_uid) < ($7, $8)")
+	require.Equal(t, []interface{}{"test", "wbpay", from, minAmount, maxAmount, "Vivienne Sabo", from, "uid"}, args)
+
+	sql, args, err = repo.listOrdersQuery(usecase.OrderFilter{}, nil).ToSql()
+	require.NoError(t, err)
+	require.Empty(t, args)
+	require.True(t, strings.HasSuffix(sql, "WHERE orders.deleted_at IS NULL"))
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+)
+
+func lookupCondition(field entity.LookupField, value string) (sq.Sqlizer, error) {
+	switch field {
+	case entity.LookupTrackNumber:
+		return sq.Eq{"orders.track_number": value}, nil
+	case entity.LookupCustomerID:
+		return sq.Eq{"orders.customer_id": value}, nil
+	case entity.LookupTransaction:
+		return sq.Eq{"payments.transaction": value}, nil
+	case entity.LookupRid:
+		return sq.Expr("orders.order_uid IN (SELECT order_uid FROM items WHERE rid = ?)", value), nil
+	default:
+		return nil, fmt.Errorf("unknown lookup field %q", field)
+	}
+}
+
+// FindOrders returns the orders holding the value in the lookup field, newest first.
+func (opr OrderPostgresRepository) FindOrders(
+	ctx context.Context,
+	lookup usecase.OrderLookup,
+) ([]*entity.Order, error) {
+	condition, err := lookupCondition(lookup.Field, lookup.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return opr.selectOrders(ctx, opr.ordersQuery().Where(condition), lookup.Limit)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+)
+
+// OrderChangesChannel is the channel every created, changed or deleted order is announced on.
+// The payload is "<order_uid>:<version>", or just the order uid once the order is deleted.
+const OrderChangesChannel = "order_changes"
+
+// OrderChange is a parsed OrderChangesChannel payload. Version is zero for a deleted order.
+type OrderChange struct {
+	OrderUID string
+	Version  int
+}
+
+func ParseOrderChange(payload string) OrderChange {
+	if i := strings.LastIndexByte(payload, ':'); i >= 0 {
+		if version, err := strconv.Atoi(payload[i+1:]); err == nil {
+			return OrderChange{OrderUID: payload[:i], Version: version}
+		}
+	}
+	return OrderChange{OrderUID: payload}
+}
+
+// notifyOrderChange builds the statement announcing the order on OrderChangesChannel with the version
+// it has in the transaction. The notification is delivered when the transaction commits and dropped on rollback.
+func (opr OrderPostgresRepository) notifyOrderChange(orderUID string) sq.Sqlizer {
+	return opr.db.Builder.Select().Column(sq.Expr(`pg_notify(?::text, ?::text || coalesce(
+		':' || (SELECT version::text FROM orders WHERE order_uid = ?::text AND deleted_at IS NULL),
+		''
+	))`, OrderChangesChannel, orderUID, orderUID))
+}
+
+func (opr OrderPostgresRepository) notifyOrderChanged(ctx context.Context, tx pgx.Tx, orderUID string) error {
+	sql, args, err := opr.notifyOrderChange(orderUID).ToSql()
+	if err != nil {
+		return fmt.Errorf("can not build notify order change query: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("can not notify order change: %w", translateError(err))
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOrderChange(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    OrderChange
+	}{
+		{payload: "b563feb7b2b84b6test:3", want: OrderChange{OrderUID: "b563feb7b2b84b6test", Version: 3}},
+		{payload: "b563feb7b2b84b6test", want: OrderChange{OrderUID: "b563feb7b2b84b6test"}},
+		{payload: "with:colon", want: OrderChange{OrderUID: "with:colon"}},
+		{payload: "with:colon:1", want: OrderChange{OrderUID: "with:colon", Version: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.payload, func(t *testing.T) {
+			require.Equal(t, tt.want, ParseOrderChange(tt.payload))
+		})
+	}
+}
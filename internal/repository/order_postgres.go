@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 
+	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v4"
 	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
 	"github.com/maypok86/wb-l0/pkg/postgres"
 )
 
@@ -17,38 +19,10 @@ func NewOrderPostgresRepository(db *postgres.Postgres) OrderPostgresRepository {
 	return OrderPostgresRepository{db: db}
 }
 
-func (opr OrderPostgresRepository) createDelivery(ctx context.Context, tx pgx.Tx, delivery entity.Delivery) (int, error) {
-	sql, args, err := opr.db.Builder.Insert("deliveries").Columns(
-		"name",
-		"phone",
-		"zip",
-		"city",
-		"address",
-		"region",
-		"email",
-	).Suffix("RETURNING id").Values(
-		delivery.Name,
-		delivery.Phone,
-		delivery.Zip,
-		delivery.City,
-		delivery.Address,
-		delivery.Region,
-		delivery.Email,
-	).ToSql()
-	if err != nil {
-		return 0, fmt.Errorf("can not build insert delivery query: %w", err)
-	}
-
-	var deliveryID int
-	if err := tx.QueryRow(ctx, sql, args...).Scan(&deliveryID); err != nil {
-		return 0, fmt.Errorf("can not insert delivery: %w", err)
-	}
-
-	return deliveryID, nil
-}
-
-func (opr OrderPostgresRepository) createPayment(ctx context.Context, tx pgx.Tx, payment entity.Payment) error {
-	sql, args, err := opr.db.Builder.Insert("payments").Columns(
+// insertPayment builds the payment insert. It goes after the order, which the payment references.
+func (opr OrderPostgresRepository) insertPayment(orderUID string, payment entity.Payment) sq.Sqlizer {
+	return opr.db.Builder.Insert("payments").Columns(
+		"order_uid",
 		"transaction",
 		"request_id",
 		"currency",
@@ -59,31 +33,45 @@ func (opr OrderPostgresRepository) createPayment(ctx context.Context, tx pgx.Tx,
 		"delivery_cost",
 		"goods_total",
 		"custom_fee",
+		"currency_exponent",
 	).Values(
+		orderUID,
 		payment.Transaction,
 		payment.RequestID,
 		payment.Currency,
 		payment.Provider,
-		payment.Amount,
+		payment.Amount.MinorUnits(),
 		payment.PaymentDt,
 		payment.Bank,
-		payment.DeliveryCost,
-		payment.GoodsTotal,
-		payment.CustomFee,
-	).ToSql()
-	if err != nil {
-		return fmt.Errorf("can not build insert payment query: %w", err)
-	}
-
-	_, err = tx.Exec(ctx, sql, args...)
-	if err != nil {
-		return fmt.Errorf("can not insert payment: %w", err)
-	}
-	return nil
+		payment.DeliveryCost.MinorUnits(),
+		payment.GoodsTotal.MinorUnits(),
+		payment.CustomFee.MinorUnits(),
+		payment.Currency.Exponent(),
+	)
 }
 
-func (opr OrderPostgresRepository) createOrder(ctx context.Context, tx pgx.Tx, order *entity.Order, deliveryID int) error {
-	sql, args, err := opr.db.Builder.Insert("orders").Columns(
+// insertOrder builds a single statement inserting the delivery and the order referencing it.
+func (opr OrderPostgresRepository) insertOrder(order *entity.Order) sq.Sqlizer {
+	// the nested statement keeps the default placeholders, the outer builder numbers all of them
+	delivery := sq.Insert("deliveries").Columns(
+		"name",
+		"phone",
+		"zip",
+		"city",
+		"address",
+		"region",
+		"email",
+	).Values(
+		order.Delivery.Name,
+		order.Delivery.Phone,
+		order.Delivery.Zip,
+		order.Delivery.City,
+		order.Delivery.Address,
+		order.Delivery.Region,
+		order.Delivery.Email,
+	).Suffix("RETURNING id")
+
+	return opr.db.Builder.Insert("orders").PrefixExpr(sq.Expr("WITH delivery AS (?)", delivery)).Columns(
 		"order_uid",
 		"track_number",
 		"entry",
@@ -96,11 +84,13 @@ func (opr OrderPostgresRepository) createOrder(ctx context.Context, tx pgx.Tx, o
 		"sm_id",
 		"date_created",
 		"oof_shard",
+		"status",
+		"version",
 	).Values(
 		order.OrderUID,
 		order.TrackNumber,
 		order.Entry,
-		deliveryID,
+		sq.Expr("(SELECT id FROM delivery)"),
 		order.Locale,
 		order.InternalSignature,
 		order.CustomerID,
@@ -109,21 +99,25 @@ func (opr OrderPostgresRepository) createOrder(ctx context.Context, tx pgx.Tx, o
 		order.SmID,
 		order.DateCreated,
 		order.OofShard,
-	).ToSql()
-	if err != nil {
-		return fmt.Errorf("can not build insert order query: %w", err)
-	}
-
-	_, err = tx.Exec(ctx, sql, args...)
-	if err != nil {
-		return fmt.Errorf("can not insert order: %w", err)
-	}
-	return nil
+		order.Status,
+		order.Version,
+	)
 }
 
-func (opr OrderPostgresRepository) createItems(ctx context.Context, tx pgx.Tx, items []entity.Item) error {
-	for _, item := range items {
-		sql, args, err := opr.db.Builder.Insert("items").Columns(
+// maxItemsPerInsert keeps a multi-row items insert well below the limit of 65535 bind parameters.
+const maxItemsPerInsert = 1000
+
+// insertItems builds multi-row inserts of the order items, one per maxItemsPerInsert items.
+func (opr OrderPostgresRepository) insertItems(orderUID string, items []entity.Item) []sq.Sqlizer {
+	var inserts []sq.Sqlizer
+	for start := 0; start < len(items); start += maxItemsPerInsert {
+		end := start + maxItemsPerInsert
+		if end > len(items) {
+			end = len(items)
+		}
+
+		insert := opr.db.Builder.Insert("items").Columns(
+			"order_uid",
 			"chrt_id",
 			"track_number",
 			"price",
@@ -135,156 +129,220 @@ func (opr OrderPostgresRepository) createItems(ctx context.Context, tx pgx.Tx, i
 			"nm_id",
 			"brand",
 			"status",
-		).Values(
-			item.ChrtID,
-			item.TrackNumber,
-			item.Price,
-			item.Rid,
-			item.Name,
-			item.Sale,
-			item.Size,
-			item.TotalPrice,
-			item.NmID,
-			item.Brand,
-			item.Status,
-		).ToSql()
-		if err != nil {
-			return fmt.Errorf("can not build insert item query: %w", err)
+		)
+		for _, item := range items[start:end] {
+			insert = insert.Values(
+				orderUID,
+				item.ChrtID,
+				item.TrackNumber,
+				item.Price.MinorUnits(),
+				item.Rid,
+				item.Name,
+				item.Sale,
+				item.Size,
+				item.TotalPrice.MinorUnits(),
+				item.NmID,
+				item.Brand,
+				item.Status,
+			)
 		}
+		inserts = append(inserts, insert)
+	}
+	return inserts
+}
 
-		_, err = tx.Exec(ctx, sql, args...)
-		if err != nil {
-			return fmt.Errorf("can not insert item: %w", err)
-		}
+func (opr OrderPostgresRepository) createItems(
+	ctx context.Context,
+	tx pgx.Tx,
+	orderUID string,
+	items []entity.Item,
+) error {
+	statements := make([]statement, 0, len(items)/maxItemsPerInsert+1)
+	for _, insert := range opr.insertItems(orderUID, items) {
+		statements = append(statements, statement{name: "insert items", query: insert})
 	}
-	return nil
+	return execBatch(ctx, tx, statements)
 }
 
-func (opr OrderPostgresRepository) CreateOrder(ctx context.Context, order *entity.Order) (*entity.Order, error) {
-	tx, err := opr.db.Pool.Begin(ctx)
+// insertStatusHistory builds a multi-row insert of the status changes. The changes must not be empty.
+func (opr OrderPostgresRepository) insertStatusHistory(orderUID string, changes []entity.StatusChange) sq.Sqlizer {
+	insert := opr.db.Builder.Insert("order_status_history").Columns(
+		"order_uid",
+		"status",
+		"reason",
+		"created_at",
+	)
+	for _, change := range changes {
+		insert = insert.Values(orderUID, change.Status, change.Reason, change.ChangedAt)
+	}
+	return insert
+}
+
+func (opr OrderPostgresRepository) createStatusChange(
+	ctx context.Context,
+	tx pgx.Tx,
+	orderUID string,
+	change entity.StatusChange,
+) error {
+	sql, args, err := opr.db.Builder.Insert("order_status_history").Columns(
+		"order_uid",
+		"status",
+		"reason",
+		"created_at",
+	).Values(
+		orderUID,
+		change.Status,
+		change.Reason,
+		change.ChangedAt,
+	).ToSql()
 	if err != nil {
-		return nil, fmt.Errorf("can not begin transaction: %w", err)
+		return fmt.Errorf("can not build insert status change query: %w", err)
 	}
-	defer tx.Rollback(ctx)
 
-	deliveryID, err := opr.createDelivery(ctx, tx, order.Delivery)
+	_, err = tx.Exec(ctx, sql, args...)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("can not insert status change: %w", translateError(err))
 	}
+	return nil
+}
 
-	if err := opr.createPayment(ctx, tx, order.Payment); err != nil {
-		return nil, err
+// createOrder writes all rows of the order with a single batch, so it costs one round trip.
+func (opr OrderPostgresRepository) createOrder(ctx context.Context, tx pgx.Tx, order *entity.Order) error {
+	statements := []statement{
+		{name: "insert order", query: opr.insertOrder(order)},
+		{name: "insert payment", query: opr.insertPayment(order.OrderUID, order.Payment)},
+	}
+	for _, insert := range opr.insertItems(order.OrderUID, order.Items) {
+		statements = append(statements, statement{name: "insert items", query: insert})
+	}
+	if len(order.StatusHistory) > 0 {
+		statements = append(statements, statement{
+			name:  "insert status history",
+			query: opr.insertStatusHistory(order.OrderUID, order.StatusHistory),
+		})
 	}
+	statements = append(statements, statement{name: "notify order change", query: opr.notifyOrderChange(order.OrderUID)})
 
-	if err := opr.createOrder(ctx, tx, order, deliveryID); err != nil {
-		return nil, err
+	return execBatch(ctx, tx, statements)
+}
+
+func (opr OrderPostgresRepository) CreateOrder(ctx context.Context, order *entity.Order) (*entity.Order, error) {
+	tx, err := opr.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can not begin transaction: %w", translateError(err))
 	}
+	defer tx.Rollback(ctx)
 
-	if err := opr.createItems(ctx, tx, order.Items); err != nil {
+	if err := opr.createOrder(ctx, tx, order); err != nil {
 		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("can not commit transaction: %w", err)
+		return nil, fmt.Errorf("can not commit transaction: %w", translateError(err))
 	}
 
 	return order, nil
 }
 
-func (opr OrderPostgresRepository) getItemsByTrackNumber(ctx context.Context, trackNumber string) ([]entity.Item, error) {
-	sql, args, err := opr.db.Builder.Select(
-		"chrt_id",
-		"track_number",
-		"price",
-		"rid",
-		"name",
-		"sale",
-		"size",
-		"total_price",
-		"nm_id",
-		"brand",
-		"status",
-	).From("items").Where("track_number = ?", trackNumber).ToSql()
+func (opr OrderPostgresRepository) UpdateOrderStatus(
+	ctx context.Context,
+	orderUID string,
+	from entity.OrderStatus,
+	change entity.StatusChange,
+) (*entity.Order, error) {
+	tx, err := opr.db.Pool.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("can not build select items query: %w", err)
+		return nil, fmt.Errorf("can not begin transaction: %w", translateError(err))
 	}
+	defer tx.Rollback(ctx)
 
-	rows, err := opr.db.Pool.Query(ctx, sql, args...)
+	query := opr.db.Builder.Update("orders").
+		Set("status", change.Status).
+		Set("version", sq.Expr("version + 1")).
+		Set("changed_at", sq.Expr("now()")).
+		Where("order_uid = ? AND status = ? AND deleted_at IS NULL", orderUID, from)
+	if change.Status == entity.StatusCancelled {
+		query = query.Set("cancelled_at", change.ChangedAt)
+	}
+
+	sql, args, err := query.ToSql()
 	if err != nil {
-		return nil, fmt.Errorf("can not select items: %w", err)
+		return nil, fmt.Errorf("can not build update order status query: %w", err)
 	}
-	defer rows.Close()
 
-	const defaultItemsCapacity = 64
-	items := make([]entity.Item, 0, defaultItemsCapacity)
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can not update order status: %w", translateError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, usecase.NewError(
+			usecase.ErrConflict,
+			fmt.Errorf("can not update order status: order %s is not in status %s", orderUID, from),
+		)
+	}
 
-	for rows.Next() {
-		var item entity.Item
-		if err := rows.Scan(
-			&item.ChrtID,
-			&item.TrackNumber,
-			&item.Price,
-			&item.Rid,
-			&item.Name,
-			&item.Sale,
-			&item.Size,
-			&item.TotalPrice,
-			&item.NmID,
-			&item.Brand,
-			&item.Status,
-		); err != nil {
-			return nil, fmt.Errorf("can not scan item: %w", err)
-		}
+	if err := opr.createStatusChange(ctx, tx, orderUID, change); err != nil {
+		return nil, err
+	}
+
+	if err := opr.notifyOrderChanged(ctx, tx, orderUID); err != nil {
+		return nil, err
+	}
 
-		items = append(items, item)
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("can not commit transaction: %w", translateError(err))
 	}
 
-	return items, nil
+	return opr.GetOrderByID(ctx, orderUID)
 }
 
-func (opr OrderPostgresRepository) GetOrderByID(ctx context.Context, orderUID string) (*entity.Order, error) {
-	order := &entity.Order{}
-	sql, args, err := opr.db.Builder.Select(
-		"deliveries.name",
-		"deliveries.phone",
-		"deliveries.zip",
-		"deliveries.city",
-		"deliveries.address",
-		"deliveries.region",
-		"deliveries.email",
-
-		"payments.transaction",
-		"payments.request_id",
-		"payments.currency",
-		"payments.provider",
-		"payments.amount",
-		"payments.payment_dt",
-		"payments.bank",
-		"payments.delivery_cost",
-		"payments.goods_total",
-		"payments.custom_fee",
-
-		"orders.order_uid",
-		"orders.track_number",
-		"orders.entry",
-		"orders.locale",
-		"orders.internal_signature",
-		"orders.customer_id",
-		"orders.delivery_service",
-		"orders.shardkey",
-		"orders.sm_id",
-		"orders.date_created",
-		"orders.oof_shard",
-	).From("orders").Join(
-		"deliveries ON orders.delivery_id = deliveries.id",
-	).Join(
-		"payments ON orders.order_uid = payments.transaction",
-	).Where("orders.order_uid = ?", orderUID).Limit(1).ToSql()
-	if err != nil {
-		return nil, fmt.Errorf("can not build select order by id query: %w", err)
-	}
+var orderColumns = []string{
+	"deliveries.name",
+	"deliveries.phone",
+	"deliveries.zip",
+	"deliveries.city",
+	"deliveries.address",
+	"deliveries.region",
+	"deliveries.email",
+
+	"payments.transaction",
+	"payments.request_id",
+	"payments.currency",
+	"payments.provider",
+	"payments.amount",
+	"payments.payment_dt",
+	"payments.bank",
+	"payments.delivery_cost",
+	"payments.goods_total",
+	"payments.custom_fee",
+	"payments.currency_exponent",
+
+	"orders.order_uid",
+	"orders.track_number",
+	"orders.entry",
+	"orders.locale",
+	"orders.internal_signature",
+	"orders.customer_id",
+	"orders.delivery_service",
+	"orders.shardkey",
+	"orders.sm_id",
+	"orders.date_created",
+	"orders.oof_shard",
+	"orders.status",
+	"orders.version",
+	"orders.cancelled_at",
+}
 
-	if err := opr.db.Pool.QueryRow(ctx, sql, args...).Scan(
+func scanOrder(row pgx.Row) (*entity.Order, error) {
+	var (
+		order        = &entity.Order{}
+		amount       int64
+		deliveryCost int64
+		goodsTotal   int64
+		customFee    int64
+		exponent     int
+	)
+	if err := row.Scan(
 		&order.Delivery.Name,
 		&order.Delivery.Phone,
 		&order.Delivery.Zip,
@@ -297,12 +355,13 @@ func (opr OrderPostgresRepository) GetOrderByID(ctx context.Context, orderUID st
 		&order.Payment.RequestID,
 		&order.Payment.Currency,
 		&order.Payment.Provider,
-		&order.Payment.Amount,
+		&amount,
 		&order.Payment.PaymentDt,
 		&order.Payment.Bank,
-		&order.Payment.DeliveryCost,
-		&order.Payment.GoodsTotal,
-		&order.Payment.CustomFee,
+		&deliveryCost,
+		&goodsTotal,
+		&customFee,
+		&exponent,
 
 		&order.OrderUID,
 		&order.TrackNumber,
@@ -315,115 +374,332 @@ func (opr OrderPostgresRepository) GetOrderByID(ctx context.Context, orderUID st
 		&order.SmID,
 		&order.DateCreated,
 		&order.OofShard,
+		&order.Status,
+		&order.Version,
+		&order.CancelledAt,
 	); err != nil {
-		return nil, fmt.Errorf("can not select order by id: %w", err)
+		return nil, err
+	}
+
+	currency := order.Payment.Currency
+	if exponent != currency.Exponent() {
+		return nil, fmt.Errorf(
+			"%w: payment of order %s is stored with %d minor unit digits, %s has %d",
+			errExponentMismatch, order.OrderUID, exponent, currency, currency.Exponent(),
+		)
 	}
+	order.Payment.Amount = entity.NewMoney(amount, currency)
+	order.Payment.DeliveryCost = entity.NewMoney(deliveryCost, currency)
+	order.Payment.GoodsTotal = entity.NewMoney(goodsTotal, currency)
+	order.Payment.CustomFee = entity.NewMoney(customFee, currency)
 
-	order.Items, err = opr.getItemsByTrackNumber(ctx, order.TrackNumber)
+	return order, nil
+}
+
+// GetOrderByID returns the order with its items and status history.
+func (opr OrderPostgresRepository) GetOrderByID(ctx context.Context, orderUID string) (*entity.Order, error) {
+	orders, err := opr.selectOrders(ctx, opr.ordersQuery().Where("orders.order_uid = ?", orderUID), 1)
 	if err != nil {
 		return nil, err
 	}
+	if len(orders) == 0 {
+		return nil, usecase.NewError(usecase.ErrNotFound, fmt.Errorf("can not select order by id: order %s not found", orderUID))
+	}
 
-	return order, nil
+	return orders[0], nil
 }
 
-func (opr OrderPostgresRepository) GetAllOrders(ctx context.Context) ([]*entity.Order, error) {
-	sql, args, err := opr.db.Builder.Select(
-		"deliveries.name",
-		"deliveries.phone",
-		"deliveries.zip",
-		"deliveries.city",
-		"deliveries.address",
-		"deliveries.region",
-		"deliveries.email",
-
-		"payments.transaction",
-		"payments.request_id",
-		"payments.currency",
-		"payments.provider",
-		"payments.amount",
-		"payments.payment_dt",
-		"payments.bank",
-		"payments.delivery_cost",
-		"payments.goods_total",
-		"payments.custom_fee",
-
-		"orders.order_uid",
-		"orders.track_number",
-		"orders.entry",
-		"orders.locale",
-		"orders.internal_signature",
-		"orders.customer_id",
-		"orders.delivery_service",
-		"orders.shardkey",
-		"orders.sm_id",
-		"orders.date_created",
-		"orders.oof_shard",
-	).From("orders").Join(
+// ordersQuery selects the columns scanned by scanOrder for all orders that are not deleted.
+func (opr OrderPostgresRepository) ordersQuery() sq.SelectBuilder {
+	return opr.db.Builder.Select(orderColumns...).From("orders").Join(
 		"deliveries ON orders.delivery_id = deliveries.id",
 	).Join(
-		"payments ON orders.order_uid = payments.transaction",
-	).ToSql()
+		"payments ON payments.order_uid = orders.order_uid",
+	).Where("orders.deleted_at IS NULL")
+}
+
+// GetOrdersBatch returns a page of orders, newest first. Pages are keyset-paginated
+// by (date_created, order_uid), so reading a page costs the same regardless of its position.
+func (opr OrderPostgresRepository) GetOrdersBatch(
+	ctx context.Context,
+	batch usecase.OrderBatch,
+) ([]*entity.Order, error) {
+	query := batchBounds(opr.ordersQuery(), batch)
+	if batch.After != nil {
+		query = query.Where(
+			"(orders.date_created, orders.order_uid) < (?, ?)",
+			batch.After.DateCreated,
+			batch.After.OrderUID,
+		)
+	}
+
+	return opr.selectOrders(ctx, query, batch.Limit)
+}
+
+// selectOrders runs the orders query, newest first, and attaches the items and status history
+// to the selected orders. Whatever the number of orders, it takes two round trips.
+func (opr OrderPostgresRepository) selectOrders(
+	ctx context.Context,
+	query sq.SelectBuilder,
+	limit int,
+) ([]*entity.Order, error) {
+	sql, args, err := query.
+		OrderBy("orders.date_created DESC", "orders.order_uid DESC").
+		Limit(uint64(limit)).
+		ToSql()
 	if err != nil {
-		return nil, fmt.Errorf("can not build select all orders query: %w", err)
+		return nil, fmt.Errorf("can not build select orders query: %w", err)
 	}
 
 	rows, err := opr.db.Pool.Query(ctx, sql, args...)
 	if err != nil {
-		return nil, fmt.Errorf("can not select items: %w", err)
+		return nil, fmt.Errorf("can not select orders: %w", translateError(err))
+	}
+	orders, err := scanOrders(rows, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
 	}
-	defer rows.Close()
 
-	const defaultOrdersCapacity = 64
-	orders := make([]*entity.Order, 0, defaultOrdersCapacity)
+	if err := opr.attachDetails(ctx, orders); err != nil {
+		return nil, err
+	}
 
+	return orders, nil
+}
+
+func scanOrders(rows pgx.Rows, limit int) ([]*entity.Order, error) {
+	defer rows.Close()
+
+	orders := make([]*entity.Order, 0, limit)
 	for rows.Next() {
-		order := &entity.Order{}
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can not scan order: %w", translateError(err))
+		}
 
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can not select orders: %w", translateError(err))
+	}
+
+	return orders, nil
+}
+
+// attachDetails fetches the items and status history of the orders with a single batch.
+func (opr OrderPostgresRepository) attachDetails(ctx context.Context, orders []*entity.Order) error {
+	ordersByUID := make(map[string]*entity.Order, len(orders))
+	orderUIDs := make([]string, 0, len(orders))
+	for _, order := range orders {
+		ordersByUID[order.OrderUID] = order
+		orderUIDs = append(orderUIDs, order.OrderUID)
+	}
+
+	batch, err := opr.detailsBatch(orderUIDs)
+	if err != nil {
+		return err
+	}
+
+	results := opr.db.Pool.SendBatch(ctx, batch)
+	defer results.Close()
+
+	return readDetails(results, ordersByUID)
+}
+
+// detailsBatch queues the items query and then the status history query of the orders.
+func (opr OrderPostgresRepository) detailsBatch(orderUIDs []string) (*pgx.Batch, error) {
+	itemsSQL, itemsArgs, err := opr.db.Builder.Select(itemColumns...).
+		From("items").
+		Where("order_uid = ANY(?)", orderUIDs).
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("can not build select items query: %w", err)
+	}
+
+	historySQL, historyArgs, err := opr.db.Builder.Select(
+		"order_uid",
+		"status",
+		"reason",
+		"created_at",
+	).From("order_status_history").Where("order_uid = ANY(?)", orderUIDs).OrderBy("created_at", "id").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("can not build select status history query: %w", err)
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(itemsSQL, itemsArgs...)
+	batch.Queue(historySQL, historyArgs...)
+	return batch, nil
+}
+
+// readDetails attaches the results of detailsBatch to the orders.
+func readDetails(results pgx.BatchResults, ordersByUID map[string]*entity.Order) error {
+	rows, err := results.Query()
+	if err != nil {
+		return fmt.Errorf("can not select items: %w", translateError(err))
+	}
+	if err := scanItems(rows, ordersByUID); err != nil {
+		return err
+	}
+
+	rows, err = results.Query()
+	if err != nil {
+		return fmt.Errorf("can not select status history: %w", translateError(err))
+	}
+	if err := scanStatusHistory(rows, ordersByUID); err != nil {
+		return err
+	}
+
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("can not close batch: %w", translateError(err))
+	}
+	return nil
+}
+
+var itemColumns = []string{
+	"order_uid",
+	"chrt_id",
+	"track_number",
+	"price",
+	"rid",
+	"name",
+	"sale",
+	"size",
+	"total_price",
+	"nm_id",
+	"brand",
+	"status",
+}
+
+// scanItems appends the scanned items to their orders. Prices take the currency of the order payment.
+func scanItems(rows pgx.Rows, ordersByUID map[string]*entity.Order) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			orderUID   string
+			item       entity.Item
+			price      int64
+			totalPrice int64
+		)
 		if err := rows.Scan(
-			&order.Delivery.Name,
-			&order.Delivery.Phone,
-			&order.Delivery.Zip,
-			&order.Delivery.City,
-			&order.Delivery.Address,
-			&order.Delivery.Region,
-			&order.Delivery.Email,
-
-			&order.Payment.Transaction,
-			&order.Payment.RequestID,
-			&order.Payment.Currency,
-			&order.Payment.Provider,
-			&order.Payment.Amount,
-			&order.Payment.PaymentDt,
-			&order.Payment.Bank,
-			&order.Payment.DeliveryCost,
-			&order.Payment.GoodsTotal,
-			&order.Payment.CustomFee,
-
-			&order.OrderUID,
-			&order.TrackNumber,
-			&order.Entry,
-			&order.Locale,
-			&order.InternalSignature,
-			&order.CustomerID,
-			&order.DeliveryService,
-			&order.Shardkey,
-			&order.SmID,
-			&order.DateCreated,
-			&order.OofShard,
+			&orderUID,
+			&item.ChrtID,
+			&item.TrackNumber,
+			&price,
+			&item.Rid,
+			&item.Name,
+			&item.Sale,
+			&item.Size,
+			&totalPrice,
+			&item.NmID,
+			&item.Brand,
+			&item.Status,
 		); err != nil {
-			return nil, fmt.Errorf("can not scan order: %w", err)
+			return fmt.Errorf("can not scan item: %w", translateError(err))
 		}
 
-		orders = append(orders, order)
+		order, ok := ordersByUID[orderUID]
+		if !ok {
+			continue
+		}
+		item.Price = entity.NewMoney(price, order.Payment.Currency)
+		item.TotalPrice = entity.NewMoney(totalPrice, order.Payment.Currency)
+		order.Items = append(order.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("can not select items: %w", translateError(err))
 	}
 
-	for _, order := range orders {
-		order.Items, err = opr.getItemsByTrackNumber(ctx, order.TrackNumber)
-		if err != nil {
-			return nil, err
+	return nil
+}
+
+// scanStatusHistory appends the scanned status changes to their orders.
+func scanStatusHistory(rows pgx.Rows, ordersByUID map[string]*entity.Order) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			orderUID string
+			change   entity.StatusChange
+		)
+		if err := rows.Scan(&orderUID, &change.Status, &change.Reason, &change.ChangedAt); err != nil {
+			return fmt.Errorf("can not scan status change: %w", translateError(err))
 		}
+
+		if order, ok := ordersByUID[orderUID]; ok {
+			order.StatusHistory = append(order.StatusHistory, change)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("can not select status history: %w", translateError(err))
 	}
 
-	return orders, nil
+	return nil
+}
+
+// batchBounds restricts the query to the orders created and changed within the batch bounds.
+func batchBounds(query sq.SelectBuilder, batch usecase.OrderBatch) sq.SelectBuilder {
+	if !batch.Since.IsZero() {
+		query = query.Where("orders.date_created >= ?", batch.Since)
+	}
+	if !batch.ChangedSince.IsZero() {
+		query = query.Where("orders.changed_at >= ?", batch.ChangedSince)
+	}
+	return query
+}
+
+func (opr OrderPostgresRepository) liveOrdersQuery(orderUIDs []string) sq.SelectBuilder {
+	return opr.db.Builder.Select("order_uid").
+		From("orders").
+		Where("order_uid = ANY(?) AND deleted_at IS NULL", orderUIDs)
+}
+
+// LiveOrders returns the uids of the given orders which are stored and not deleted.
+func (opr OrderPostgresRepository) LiveOrders(ctx context.Context, orderUIDs []string) ([]string, error) {
+	sql, args, err := opr.liveOrdersQuery(orderUIDs).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("can not build select live orders query: %w", err)
+	}
+
+	rows, err := opr.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("can not select live orders: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	live := make([]string, 0, len(orderUIDs))
+	for rows.Next() {
+		var orderUID string
+		if err := rows.Scan(&orderUID); err != nil {
+			return nil, fmt.Errorf("can not scan order uid: %w", translateError(err))
+		}
+		live = append(live, orderUID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can not select live orders: %w", translateError(err))
+	}
+
+	return live, nil
+}
+
+// CountOrders returns the number of orders within the batch bounds, its After and Limit are ignored.
+func (opr OrderPostgresRepository) CountOrders(ctx context.Context, batch usecase.OrderBatch) (int, error) {
+	query := batchBounds(opr.db.Builder.Select("count(*)").From("orders").Where("orders.deleted_at IS NULL"), batch)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("can not build count orders query: %w", err)
+	}
+
+	var count int
+	if err := opr.db.Pool.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("can not count orders: %w", translateError(err))
+	}
+
+	return count, nil
 }
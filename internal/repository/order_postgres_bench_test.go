@@ -0,0 +1,196 @@
+//go:build bench
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/pkg/postgres"
+	"github.com/stretchr/testify/require"
+)
+
+// The benchmarks need a migrated database configured with the POSTGRES_* variables of the app, e.g.
+//
+//	go test -tags bench -run '^$' -bench . ./internal/repository
+func newBenchRepository(b *testing.B) OrderPostgresRepository {
+	b.Helper()
+
+	if os.Getenv("POSTGRES_HOST") == "" {
+		b.Skip("POSTGRES_HOST is not set")
+	}
+	db, err := postgres.New(context.Background(), postgres.NewConnectionConfig(
+		os.Getenv("POSTGRES_HOST"),
+		os.Getenv("POSTGRES_PORT"),
+		os.Getenv("POSTGRES_DBNAME"),
+		os.Getenv("POSTGRES_USER"),
+		os.Getenv("POSTGRES_PASSWORD"),
+		os.Getenv("POSTGRES_SSLMODE"),
+	), postgres.ConnAttempts(1))
+	require.NoError(b, err)
+	b.Cleanup(db.Close)
+
+	return NewOrderPostgresRepository(db)
+}
+
+func benchOrder(i, items int) *entity.Order {
+	uid := fmt.Sprintf("bench-%d-%d", time.Now().UnixNano(), i)
+	order := &entity.Order{
+		OrderUID:    uid,
+		TrackNumber: uid,
+		Entry:       "WBIL",
+		Delivery:    entity.Delivery{Name: "Test Testov", Phone: "+9720000000", City: "Kiryat Mozkin"},
+		Payment: entity.Payment{
+			Transaction:  uid,
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       entity.NewMoney(1817, "USD"),
+			DeliveryCost: entity.NewMoney(1500, "USD"),
+			GoodsTotal:   entity.NewMoney(317, "USD"),
+			CustomFee:    entity.NewMoney(0, "USD"),
+		},
+		Locale:      "en",
+		CustomerID:  "test",
+		DateCreated: time.Now(),
+		Status:      entity.StatusCreated,
+		Version:     1,
+		StatusHistory: []entity.StatusChange{
+			{Status: entity.StatusCreated, Reason: "ingested", ChangedAt: time.Now()},
+		},
+	}
+	for j := 0; j < items; j++ {
+		order.Items = append(order.Items, entity.Item{
+			ChrtID:      j,
+			TrackNumber: uid,
+			Price:       entity.NewMoney(453, "USD"),
+			Rid:         fmt.Sprintf("%s-%d", uid, j),
+			Name:        "Mascaras",
+			TotalPrice:  entity.NewMoney(317, "USD"),
+			Brand:       "Vivienne Sabo",
+			Status:      202,
+		})
+	}
+	return order
+}
+
+// createOrderOneByOne is the former insert path: a round trip per row.
+func (opr OrderPostgresRepository) createOrderOneByOne(ctx context.Context, tx pgx.Tx, order *entity.Order) error {
+	exec := func(query sq.Sqlizer) error {
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, sql, args...)
+		return err
+	}
+
+	sql, args, err := opr.db.Builder.Insert("deliveries").
+		Columns("name", "phone", "zip", "city", "address", "region", "email").
+		Values(
+			order.Delivery.Name,
+			order.Delivery.Phone,
+			order.Delivery.Zip,
+			order.Delivery.City,
+			order.Delivery.Address,
+			order.Delivery.Region,
+			order.Delivery.Email,
+		).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	var deliveryID int
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&deliveryID); err != nil {
+		return err
+	}
+
+	if err := exec(opr.db.Builder.Insert("orders").Columns(
+		"order_uid", "track_number", "entry", "delivery_id", "locale", "internal_signature", "customer_id",
+		"delivery_service", "shardkey", "sm_id", "date_created", "oof_shard", "status", "version",
+	).Values(
+		order.OrderUID, order.TrackNumber, order.Entry, deliveryID, order.Locale, order.InternalSignature,
+		order.CustomerID, order.DeliveryService, order.Shardkey, order.SmID, order.DateCreated, order.OofShard,
+		order.Status, order.Version,
+	)); err != nil {
+		return err
+	}
+	if err := exec(opr.insertPayment(order.OrderUID, order.Payment)); err != nil {
+		return err
+	}
+	for _, item := range order.Items {
+		if err := exec(opr.insertItems(order.OrderUID, []entity.Item{item})[0]); err != nil {
+			return err
+		}
+	}
+	for _, change := range order.StatusHistory {
+		if err := exec(opr.insertStatusHistory(order.OrderUID, []entity.StatusChange{change})); err != nil {
+			return err
+		}
+	}
+	return exec(opr.notifyOrderChange(order.OrderUID))
+}
+
+// BenchmarkCreateOrder writes orders in transactions which are rolled back, so the database is left intact.
+func BenchmarkCreateOrder(b *testing.B) {
+	repo := newBenchRepository(b)
+	ctx := context.Background()
+
+	paths := []struct {
+		name   string
+		create func(context.Context, pgx.Tx, *entity.Order) error
+	}{
+		{name: "one-by-one", create: repo.createOrderOneByOne},
+		{name: "batch", create: repo.createOrder},
+	}
+	for _, items := range []int{1, 10, 50} {
+		for _, path := range paths {
+			b.Run(fmt.Sprintf("%s/items=%d", path.name, items), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					order := benchOrder(i, items)
+
+					tx, err := repo.db.Pool.Begin(ctx)
+					require.NoError(b, err)
+					require.NoError(b, path.create(ctx, tx, order))
+					require.NoError(b, tx.Rollback(ctx))
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkGetOrderByID reads back a committed order, which is purged when the benchmark ends.
+func BenchmarkGetOrderByID(b *testing.B) {
+	repo := newBenchRepository(b)
+	ctx := context.Background()
+
+	for _, items := range []int{1, 10, 50} {
+		b.Run(fmt.Sprintf("items=%d", items), func(b *testing.B) {
+			order := benchOrder(0, items)
+			_, err := repo.CreateOrder(ctx, order)
+			require.NoError(b, err)
+			b.Cleanup(func() {
+				require.NoError(b, repo.PurgeOrder(ctx, order.OrderUID))
+			})
+
+			got, err := repo.GetOrderByID(ctx, order.OrderUID)
+			require.NoError(b, err)
+			require.Equal(b, order.Items, got.Items)
+			require.Len(b, got.StatusHistory, len(order.StatusHistory))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := repo.GetOrderByID(ctx, order.OrderUID)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
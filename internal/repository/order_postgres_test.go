@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/pkg/postgres"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRepository() OrderPostgresRepository {
+	return NewOrderPostgresRepository(&postgres.Postgres{Builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar)})
+}
+
+func TestOrderPostgresRepository_insertOrder(t *testing.T) {
+	sql, args, err := newTestRepository().insertOrder(&entity.Order{OrderUID: "uid"}).ToSql()
+	require.NoError(t, err)
+
+	require.True(t, strings.HasPrefix(sql, "WITH delivery AS (INSERT INTO deliveries"))
+	require.Contains(t, sql, "(SELECT id FROM delivery)")
+	// the placeholders of the nested delivery insert are numbered along with the order ones
+	require.Len(t, args, 20)
+	require.Contains(t, sql, "VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING id")
+	require.Contains(t, sql, "VALUES ($8,$9,$10,(SELECT id FROM delivery),$11,")
+	require.NotContains(t, sql, "$21")
+	require.Equal(t, "uid", args[7])
+}
+
+func TestOrderPostgresRepository_insertItems(t *testing.T) {
+	repo := newTestRepository()
+	require.Empty(t, repo.insertItems("uid", nil))
+
+	items := make([]entity.Item, 2*maxItemsPerInsert+1)
+	inserts := repo.insertItems("uid", items)
+	require.Len(t, inserts, 3)
+
+	sql, args, err := inserts[0].ToSql()
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(sql, "INSERT INTO items"))
+	require.Len(t, args, 12*maxItemsPerInsert)
+	require.Equal(t, "uid", args[0])
+
+	_, args, err = inserts[2].ToSql()
+	require.NoError(t, err)
+	require.Len(t, args, 12)
+}
+
+func TestOrderPostgresRepository_insertStatusHistory(t *testing.T) {
+	changes := []entity.StatusChange{{Status: entity.StatusCreated}, {Status: entity.StatusPaid}}
+
+	sql, args, err := newTestRepository().insertStatusHistory("uid", changes).ToSql()
+	require.NoError(t, err)
+	require.Contains(t, sql, "VALUES ($1,$2,$3,$4),($5,$6,$7,$8)")
+	require.Len(t, args, 8)
+}
+
+func TestOrderPostgresRepository_insertPayment(t *testing.T) {
+	sql, args, err := newTestRepository().insertPayment("uid", entity.Payment{Transaction: "transaction"}).ToSql()
+	require.NoError(t, err)
+
+	require.True(t, strings.HasPrefix(sql, "INSERT INTO payments (order_uid,transaction,"))
+	require.Equal(t, []interface{}{"uid", "transaction"}, args[:2])
+}
+
+func TestOrderPostgresRepository_liveOrdersQuery(t *testing.T) {
+	uids := []string{"a", "b"}
+	sql, args, err := newTestRepository().liveOrdersQuery(uids).ToSql()
+	require.NoError(t, err)
+
+	require.Equal(t, "SELECT order_uid FROM orders WHERE order_uid = ANY($1) AND deleted_at IS NULL", sql)
+	require.Equal(t, []interface{}{uids}, args)
+}
+
+// fakeRows serves rows of values, converting each value to the type of its scan destination.
+type fakeRows struct {
+	pgx.Rows
+	rows   [][]interface{}
+	next   int
+	closed bool
+}
+
+func (r *fakeRows) Next() bool {
+	if r.next == len(r.rows) {
+		return false
+	}
+	r.next++
+	return true
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	row := r.rows[r.next-1]
+	if len(dest) != len(row) {
+		return fmt.Errorf("can not scan %d values into %d destinations", len(row), len(dest))
+	}
+	for i, value := range row {
+		target := reflect.ValueOf(dest[i]).Elem()
+		if value == nil {
+			target.Set(reflect.Zero(target.Type()))
+			continue
+		}
+		target.Set(reflect.ValueOf(value).Convert(target.Type()))
+	}
+	return nil
+}
+
+func (r *fakeRows) Err() error {
+	return nil
+}
+
+func (r *fakeRows) Close() {
+	r.closed = true
+}
+
+// fakeBatchResults returns the rows of the queued queries in order.
+type fakeBatchResults struct {
+	pgx.BatchResults
+	results []*fakeRows
+}
+
+func (b *fakeBatchResults) Query() (pgx.Rows, error) {
+	rows := b.results[0]
+	b.results = b.results[1:]
+	return rows, nil
+}
+
+func (b *fakeBatchResults) Close() error {
+	return nil
+}
+
+// orderRow is a row of the orders query in the order of orderColumns.
+func orderRow(orderUID string, currency entity.Currency, dateCreated time.Time) []interface{} {
+	return []interface{}{
+		"Test Testov", "+9720000000", "2639809", "Kiryat Mozkin", "Ploshad Mira 15", "Kraiot", "test@gmail.com",
+		orderUID, "", string(currency), "wbpay", int64(1817), 1637907727, "alpha", int64(1500), int64(317), int64(0), 2,
+		orderUID, "WBILMTESTTRACK", "WBIL", "en", "", "test", "meest", "9", 99, dateCreated, "1", "created", 1, nil,
+	}
+}
+
+func itemRow(orderUID string, chrtID int, price int64) []interface{} {
+	return []interface{}{orderUID, chrtID, "WBILMTESTTRACK", price, "rid", "Mascaras", 30, "0", price, 2389212, "Vivienne Sabo", 202}
+}
+
+func historyRow(orderUID string, status entity.OrderStatus, changedAt time.Time) []interface{} {
+	return []interface{}{orderUID, string(status), "", changedAt}
+}
+
+func TestScanOrders(t *testing.T) {
+	createdAt := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	rows := &fakeRows{rows: [][]interface{}{
+		orderRow("uid-2", "USD", createdAt.Add(time.Hour)),
+		orderRow("uid-1", "RUB", createdAt),
+	}}
+
+	orders, err := scanOrders(rows, 2)
+	require.NoError(t, err)
+	require.True(t, rows.closed)
+
+	require.Len(t, orders, 2)
+	require.Equal(t, "uid-2", orders[0].OrderUID)
+	require.Equal(t, "uid-2", orders[0].Payment.Transaction)
+	require.Equal(t, createdAt.Add(time.Hour), orders[0].DateCreated)
+	require.Equal(t, entity.NewMoney(1817, "USD"), orders[0].Payment.Amount)
+	require.Equal(t, entity.NewMoney(1500, "USD"), orders[0].Payment.DeliveryCost)
+	require.Equal(t, "uid-1", orders[1].OrderUID)
+	require.Equal(t, entity.NewMoney(317, "RUB"), orders[1].Payment.GoodsTotal)
+	require.Equal(t, "test@gmail.com", orders[1].Delivery.Email)
+	require.Nil(t, orders[1].CancelledAt)
+	require.Empty(t, orders[1].Items)
+}
+
+func TestScanOrders_ExponentMismatch(t *testing.T) {
+	// the row is stored with two minor unit digits, the yen has none
+	rows := &fakeRows{rows: [][]interface{}{orderRow("uid", "JPY", time.Now())}}
+
+	_, err := scanOrders(rows, 1)
+	require.ErrorIs(t, err, errExponentMismatch)
+	require.True(t, rows.closed)
+}
+
+func TestReadDetails(t *testing.T) {
+	createdAt := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	orders, err := scanOrders(&fakeRows{rows: [][]interface{}{
+		orderRow("uid-2", "USD", createdAt.Add(time.Hour)),
+		orderRow("uid-1", "RUB", createdAt),
+	}}, 2)
+	require.NoError(t, err)
+	ordersByUID := map[string]*entity.Order{"uid-1": orders[1], "uid-2": orders[0]}
+
+	batch, err := newTestRepository().detailsBatch([]string{"uid-2", "uid-1"})
+	require.NoError(t, err)
+	require.Equal(t, 2, batch.Len())
+
+	// items of different orders come interleaved in the id order, uid-2 has no status history
+	items := &fakeRows{rows: [][]interface{}{
+		itemRow("uid-1", 1, 100),
+		itemRow("uid-2", 2, 200),
+		itemRow("uid-1", 3, 300),
+		itemRow("unknown", 4, 400),
+	}}
+	history := &fakeRows{rows: [][]interface{}{
+		historyRow("uid-1", entity.StatusCreated, createdAt),
+		historyRow("uid-1", entity.StatusPaid, createdAt.Add(time.Minute)),
+	}}
+	require.NoError(t, readDetails(&fakeBatchResults{results: []*fakeRows{items, history}}, ordersByUID))
+	require.True(t, items.closed)
+	require.True(t, history.closed)
+
+	first := ordersByUID["uid-1"]
+	require.Len(t, first.Items, 2)
+	require.Equal(t, 1, first.Items[0].ChrtID)
+	require.Equal(t, 3, first.Items[1].ChrtID)
+	require.Equal(t, entity.NewMoney(300, "RUB"), first.Items[1].Price)
+	require.Equal(t, []entity.StatusChange{
+		{Status: entity.StatusCreated, ChangedAt: createdAt},
+		{Status: entity.StatusPaid, ChangedAt: createdAt.Add(time.Minute)},
+	}, first.StatusHistory)
+
+	second := ordersByUID["uid-2"]
+	require.Len(t, second.Items, 1)
+	require.Equal(t, 2, second.Items[0].ChrtID)
+	require.Equal(t, entity.NewMoney(200, "USD"), second.Items[0].TotalPrice)
+	require.Empty(t, second.StatusHistory)
+}
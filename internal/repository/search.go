@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+)
+
+// searchConfig is the text search configuration of items.search_vector. Item names come in many languages,
+// so words are not stemmed.
+const searchConfig = "simple"
+
+func (opr OrderPostgresRepository) searchOrdersQuery(search usecase.OrderSearch) sq.SelectBuilder {
+	// an order ranks as high as the best matching of its items
+	return opr.ordersQuery().Join(
+		"(SELECT order_uid, max(ts_rank(search_vector, query)) AS rank"+
+			" FROM items, websearch_to_tsquery('"+searchConfig+"', ?) AS query"+
+			" WHERE search_vector @@ query GROUP BY order_uid)"+
+			" AS matches ON matches.order_uid = orders.order_uid",
+		search.Query,
+	).OrderBy("matches.rank DESC")
+}
+
+// SearchOrders returns the orders with items whose name or brand match the query, the best matches first.
+// The query takes the web search syntax: quoted phrases, "or" and "-" for the words to exclude.
+func (opr OrderPostgresRepository) SearchOrders(
+	ctx context.Context,
+	search usecase.OrderSearch,
+) ([]*entity.Order, error) {
+	return opr.selectOrders(ctx, opr.searchOrdersQuery(search), search.Limit)
+}
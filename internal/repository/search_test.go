@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderPostgresRepository_searchOrdersQuery(t *testing.T) {
+	sql, args, err := newTestRepository().searchOrdersQuery(usecase.OrderSearch{Query: "vivienne mascaras"}).ToSql()
+	require.NoError(t, err)
+
+	require.Contains(t, sql, "JOIN (SELECT order_uid, max(ts_rank(search_vector, query)) AS rank"+
+		" FROM items, websearch_to_tsquery('simple', $1) AS query")
+	require.True(t, strings.HasSuffix(sql, "WHERE orders.deleted_at IS NULL ORDER BY matches.rank DESC"))
+	require.Equal(t, []interface{}{"vivienne mascaras"}, args)
+}
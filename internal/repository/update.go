@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+)
+
+type lockedOrder struct {
+	deliveryID int
+	version    int
+}
+
+func (opr OrderPostgresRepository) lockOrder(ctx context.Context, tx pgx.Tx, orderUID string) (lockedOrder, error) {
+	sql, args, err := opr.db.Builder.Select(
+		"delivery_id",
+		"version",
+	).From("orders").Where("order_uid = ? AND deleted_at IS NULL", orderUID).Suffix("FOR UPDATE").ToSql()
+	if err != nil {
+		return lockedOrder{}, fmt.Errorf("can not build lock order query: %w", err)
+	}
+
+	var locked lockedOrder
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&locked.deliveryID, &locked.version); err != nil {
+		return lockedOrder{}, fmt.Errorf("can not lock order: %w", translateError(err))
+	}
+
+	return locked, nil
+}
+
+func (opr OrderPostgresRepository) updateDelivery(
+	ctx context.Context,
+	tx pgx.Tx,
+	deliveryID int,
+	delivery entity.Delivery,
+) error {
+	sql, args, err := opr.db.Builder.Update("deliveries").SetMap(map[string]interface{}{
+		"name":    delivery.Name,
+		"phone":   delivery.Phone,
+		"zip":     delivery.Zip,
+		"city":    delivery.City,
+		"address": delivery.Address,
+		"region":  delivery.Region,
+		"email":   delivery.Email,
+	}).Where("id = ?", deliveryID).ToSql()
+	if err != nil {
+		return fmt.Errorf("can not build update delivery query: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("can not update delivery: %w", translateError(err))
+	}
+	return nil
+}
+
+func (opr OrderPostgresRepository) updatePayment(
+	ctx context.Context,
+	tx pgx.Tx,
+	orderUID string,
+	payment entity.Payment,
+) error {
+	sql, args, err := opr.db.Builder.Update("payments").SetMap(map[string]interface{}{
+		"transaction":       payment.Transaction,
+		"request_id":        payment.RequestID,
+		"currency":          payment.Currency,
+		"provider":          payment.Provider,
+		"amount":            payment.Amount.MinorUnits(),
+		"payment_dt":        payment.PaymentDt,
+		"bank":              payment.Bank,
+		"delivery_cost":     payment.DeliveryCost.MinorUnits(),
+		"goods_total":       payment.GoodsTotal.MinorUnits(),
+		"custom_fee":        payment.CustomFee.MinorUnits(),
+		"currency_exponent": payment.Currency.Exponent(),
+	}).Where("order_uid = ?", orderUID).ToSql()
+	if err != nil {
+		return fmt.Errorf("can not build update payment query: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("can not update payment: %w", translateError(err))
+	}
+	return nil
+}
+
+func (opr OrderPostgresRepository) updateOrder(ctx context.Context, tx pgx.Tx, order *entity.Order) error {
+	sql, args, err := opr.db.Builder.Update("orders").SetMap(map[string]interface{}{
+		"track_number":       order.TrackNumber,
+		"entry":              order.Entry,
+		"locale":             order.Locale,
+		"internal_signature": order.InternalSignature,
+		"customer_id":        order.CustomerID,
+		"delivery_service":   order.DeliveryService,
+		"shardkey":           order.Shardkey,
+		"sm_id":              order.SmID,
+		"date_created":       order.DateCreated,
+		"oof_shard":          order.OofShard,
+		"version":            sq.Expr("version + 1"),
+		"changed_at":         sq.Expr("now()"),
+	}).Where("order_uid = ?", order.OrderUID).ToSql()
+	if err != nil {
+		return fmt.Errorf("can not build update order query: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("can not update order: %w", translateError(err))
+	}
+	return nil
+}
+
+func (opr OrderPostgresRepository) deleteItems(ctx context.Context, tx pgx.Tx, orderUID string) error {
+	sql, args, err := opr.db.Builder.Delete("items").Where("order_uid = ?", orderUID).ToSql()
+	if err != nil {
+		return fmt.Errorf("can not build delete items query: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("can not delete items: %w", translateError(err))
+	}
+	return nil
+}
+
+// replaceOrder rewrites the locked order with the given one and bumps its version.
+// Items are deleted and inserted again, the status and its history are kept.
+func (opr OrderPostgresRepository) replaceOrder(
+	ctx context.Context,
+	tx pgx.Tx,
+	order *entity.Order,
+	locked lockedOrder,
+) error {
+	if err := opr.updateDelivery(ctx, tx, locked.deliveryID, order.Delivery); err != nil {
+		return err
+	}
+
+	if err := opr.updatePayment(ctx, tx, order.OrderUID, order.Payment); err != nil {
+		return err
+	}
+
+	if err := opr.deleteItems(ctx, tx, order.OrderUID); err != nil {
+		return err
+	}
+
+	if err := opr.updateOrder(ctx, tx, order); err != nil {
+		return err
+	}
+
+	return opr.createItems(ctx, tx, order.OrderUID, order.Items)
+}
+
+// UpdateOrder replaces the stored order if its version still equals the given one.
+func (opr OrderPostgresRepository) UpdateOrder(
+	ctx context.Context,
+	order *entity.Order,
+	version int,
+) (*entity.Order, error) {
+	tx, err := opr.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can not begin transaction: %w", translateError(err))
+	}
+	defer tx.Rollback(ctx)
+
+	locked, err := opr.lockOrder(ctx, tx, order.OrderUID)
+	if err != nil {
+		return nil, err
+	}
+	if locked.version != version {
+		return nil, usecase.NewError(usecase.ErrConflict, fmt.Errorf(
+			"can not update order %s: %w: stored version %d, got %d",
+			order.OrderUID,
+			usecase.ErrVersionMismatch,
+			locked.version,
+			version,
+		))
+	}
+
+	if err := opr.replaceOrder(ctx, tx, order, locked); err != nil {
+		return nil, err
+	}
+
+	if err := opr.notifyOrderChanged(ctx, tx, order.OrderUID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("can not commit transaction: %w", translateError(err))
+	}
+
+	return opr.GetOrderByID(ctx, order.OrderUID)
+}
@@ -2,27 +2,42 @@ package http
 
 import (
 	"context"
+	"expvar"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/maypok86/wb-l0/internal/entity"
 	v1 "github.com/maypok86/wb-l0/internal/transport/http/v1"
+	"github.com/maypok86/wb-l0/internal/usecase"
 )
 
 type OrderUsecase interface {
-	CreateOrder(context.Context, *entity.Order, time.Duration) (*entity.Order, error)
-	GetOrderByID(context.Context, string) (*entity.Order, error)
+	CreateOrder(context.Context, *entity.Order, time.Duration) (*entity.Order, usecase.CreateOutcome, error)
+	GetOrderJSON(context.Context, string) ([]byte, int, error)
+	FindOrders(context.Context, entity.LookupField, string) ([]*entity.Order, error)
+	ListOrders(context.Context, usecase.OrderFilter, usecase.OrderPage) ([]*entity.Order, *usecase.OrderCursor, error)
+	SearchOrders(context.Context, string, int) ([]*entity.Order, error)
+	UpdateOrder(context.Context, *entity.Order, int) (*entity.Order, error)
+	CancelOrder(context.Context, string, string) (*entity.Order, error)
+	DeleteOrder(context.Context, string, bool) error
 	LoadDBToCache(context.Context, time.Duration) error
+	WarmupStatus() usecase.WarmupStatus
 }
 
 type Handler struct {
 	orderUsecase OrderUsecase
+	debugVars    bool
+	v1Options    []v1.Option
 }
 
-func NewHandler(orderUsecase OrderUsecase) Handler {
+// NewHandler creates the handler of the api, v1Options configure the v1 routes.
+// The expvar counters of the service are served on /debug/vars only when debugVars is set.
+func NewHandler(orderUsecase OrderUsecase, debugVars bool, v1Options ...v1.Option) Handler {
 	return Handler{
 		orderUsecase: orderUsecase,
+		debugVars:    debugVars,
+		v1Options:    v1Options,
 	}
 }
 
@@ -31,6 +46,9 @@ func (h Handler) Init() *gin.Engine {
 
 	router.Use(gin.Recovery(), gin.Logger())
 
+	if h.debugVars {
+		router.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	}
 	h.registerAPI(router)
 
 	return router
@@ -42,7 +60,32 @@ func (h Handler) registerAPI(router *gin.Engine) {
 		api.GET("/healthcheck", func(c *gin.Context) {
 			c.Status(http.StatusOK)
 		})
-		v1Handler := v1.NewHandler(h.orderUsecase)
+		api.GET("/readiness", h.readiness)
+		v1Handler := v1.NewHandler(h.orderUsecase, h.v1Options...)
 		v1Handler.Register(api)
 	}
 }
+
+type readinessResponse struct {
+	Status   usecase.WarmupState `json:"status"`
+	Progress int                 `json:"progress"`
+	Loaded   int                 `json:"loaded"`
+	Total    int                 `json:"total"`
+}
+
+// readiness reports whether the cache warm-up is over. A failed warm-up doesn't make the service unready,
+// lookups are served from the repository in that case.
+func (h Handler) readiness(c *gin.Context) {
+	status := h.orderUsecase.WarmupStatus()
+
+	code := http.StatusServiceUnavailable
+	if status.State == usecase.WarmupDone || status.State == usecase.WarmupFailed {
+		code = http.StatusOK
+	}
+	c.JSON(code, readinessResponse{
+		Status:   status.State,
+		Progress: status.Percent(),
+		Loaded:   status.Loaded,
+		Total:    status.Total,
+	})
+}
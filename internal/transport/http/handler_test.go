@@ -1,6 +1,7 @@
 package http
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -10,13 +11,13 @@ import (
 )
 
 func TestNewHandler(t *testing.T) {
-	h := NewHandler(usecase.OrderUsecase{})
+	h := NewHandler(usecase.OrderUsecase{}, false)
 
 	require.IsType(t, Handler{}, h)
 }
 
 func TestNewHandler_Get(t *testing.T) {
-	h := NewHandler(usecase.OrderUsecase{})
+	h := NewHandler(usecase.OrderUsecase{}, false)
 
 	router := h.Init()
 
@@ -30,3 +31,36 @@ func TestNewHandler_Get(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, res.StatusCode)
 }
+
+func TestNewHandler_Readiness(t *testing.T) {
+	h := NewHandler(usecase.NewOrderUsecase(nil, nil), false)
+
+	ts := httptest.NewServer(h.Init())
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/api/readiness")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	var response readinessResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+	require.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	require.Equal(t, usecase.WarmupPending, response.Status)
+}
+
+func TestNewHandler_DebugVars(t *testing.T) {
+	for _, debugVars := range []bool{false, true} {
+		ts := httptest.NewServer(NewHandler(usecase.OrderUsecase{}, debugVars).Init())
+
+		res, err := http.Get(ts.URL + "/debug/vars")
+		require.NoError(t, err)
+		res.Body.Close()
+		ts.Close()
+
+		if debugVars {
+			require.Equal(t, http.StatusOK, res.StatusCode)
+		} else {
+			require.Equal(t, http.StatusNotFound, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/usecase"
+)
+
+const cursorKeySize = 32
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+type cursorPayload struct {
+	DateCreated time.Time `json:"d"`
+	OrderUID    string    `json:"u"`
+}
+
+// cursorCodec turns page cursors into opaque tokens signed with HMAC-SHA256. The signature covers
+// the filter of the listing too, so a token is only accepted for the listing that returned it.
+type cursorCodec struct {
+	key []byte
+}
+
+func newCursorCodec(key []byte) cursorCodec {
+	if len(key) == 0 {
+		key = make([]byte, cursorKeySize)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Errorf("can not generate cursor key: %w", err))
+		}
+	}
+	return cursorCodec{key: key}
+}
+
+func (cc cursorCodec) sign(payload []byte, filter string) []byte {
+	mac := hmac.New(sha256.New, cc.key)
+	mac.Write(payload)
+	mac.Write([]byte{0})
+	mac.Write([]byte(filter))
+	return mac.Sum(nil)
+}
+
+func (cc cursorCodec) encode(cursor *usecase.OrderCursor, filter string) (string, error) {
+	payload, err := json.Marshal(cursorPayload{DateCreated: cursor.DateCreated, OrderUID: cursor.OrderUID})
+	if err != nil {
+		return "", fmt.Errorf("can not encode cursor: %w", err)
+	}
+
+	encoding := base64.RawURLEncoding
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(cc.sign(payload, filter)), nil
+}
+
+func (cc cursorCodec) decode(token, filter string) (*usecase.OrderCursor, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+
+	encoding := base64.RawURLEncoding
+	payload, err := encoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	signature, err := encoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	if !hmac.Equal(signature, cc.sign(payload, filter)) {
+		return nil, errInvalidCursor
+	}
+
+	var decoded cursorPayload
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return nil, errInvalidCursor
+	}
+	return &usecase.OrderCursor{DateCreated: decoded.DateCreated, OrderUID: decoded.OrderUID}, nil
+}
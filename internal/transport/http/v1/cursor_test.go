@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec := newCursorCodec([]byte("secret"))
+	cursor := &usecase.OrderCursor{DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 123, time.UTC), OrderUID: "uid"}
+
+	token, err := codec.encode(cursor, "customer_id=test")
+	require.NoError(t, err)
+
+	decoded, err := codec.decode(token, "customer_id=test")
+	require.NoError(t, err)
+	require.True(t, cursor.DateCreated.Equal(decoded.DateCreated))
+	require.Equal(t, cursor.OrderUID, decoded.OrderUID)
+
+	payload, signature, _ := strings.Cut(token, ".")
+	tests := []struct {
+		name   string
+		codec  cursorCodec
+		token  string
+		filter string
+	}{
+		{name: "other filter", codec: codec, token: token, filter: "customer_id=other"},
+		{name: "other key", codec: newCursorCodec([]byte("other")), token: token, filter: "customer_id=test"},
+		{name: "no signature", codec: codec, token: payload, filter: "customer_id=test"},
+		{name: "tampered payload", codec: codec, token: "e30." + signature, filter: "customer_id=test"},
+		{name: "not base64", codec: codec, token: "!." + signature, filter: "customer_id=test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.codec.decode(tt.token, tt.filter)
+			require.ErrorIs(t, err, errInvalidCursor)
+		})
+	}
+}
+
+func TestNewCursorCodec_RandomKey(t *testing.T) {
+	first, second := newCursorCodec(nil), newCursorCodec(nil)
+	require.Len(t, first.key, cursorKeySize)
+	require.NotEqual(t, first.key, second.key)
+}
@@ -1,15 +1,91 @@
 package v1
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
 	"github.com/maypok86/wb-l0/pkg/logger"
 )
 
+var (
+	errBadRequest           = errors.New("bad request")
+	errPreconditionRequired = errors.New("precondition required")
+)
+
+const (
+	codeBadRequest           = "bad_request"
+	codePreconditionRequired = "precondition_required"
+	codePreconditionFailed   = "precondition_failed"
+	codeNotFound             = "not_found"
+	codeAlreadyExists        = "already_exists"
+	codeConflict             = "conflict"
+	codeInvalid              = "invalid"
+	codeUnavailable          = "unavailable"
+	codeInternal             = "internal"
+)
+
+// genericMessages replace the errors of the codes which may carry details of the internals,
+// e.g. queries or addresses of the storage. The full errors are only logged.
+var genericMessages = map[string]string{
+	codeUnavailable: "service is temporarily unavailable",
+	codeInternal:    "internal server error",
+}
+
 type errorResponse struct {
-	Error string `json:"error"`
+	Code   string              `json:"code"`
+	Error  string              `json:"error"`
+	Fields []entity.FieldError `json:"fields,omitempty"`
 }
 
-func newErrorResponse(c *gin.Context, code int, msg string) {
-	logger.Error(msg)
-	c.AbortWithStatusJSON(code, errorResponse{Error: msg})
+func badRequest(msg string) error {
+	return usecase.NewError(errBadRequest, errors.New(msg))
+}
+
+func preconditionRequired(msg string) error {
+	return usecase.NewError(errPreconditionRequired, errors.New(msg))
+}
+
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errBadRequest):
+		return http.StatusBadRequest, codeBadRequest
+	case errors.Is(err, errPreconditionRequired):
+		return http.StatusPreconditionRequired, codePreconditionRequired
+	case errors.Is(err, usecase.ErrVersionMismatch):
+		return http.StatusPreconditionFailed, codePreconditionFailed
+	case errors.Is(err, usecase.ErrNotFound):
+		return http.StatusNotFound, codeNotFound
+	case errors.Is(err, usecase.ErrAlreadyExists):
+		return http.StatusConflict, codeAlreadyExists
+	case errors.Is(err, usecase.ErrConflict):
+		return http.StatusConflict, codeConflict
+	case errors.Is(err, usecase.ErrInvalid):
+		return http.StatusUnprocessableEntity, codeInvalid
+	case errors.Is(err, usecase.ErrUnavailable):
+		return http.StatusServiceUnavailable, codeUnavailable
+	default:
+		return http.StatusInternalServerError, codeInternal
+	}
+}
+
+func newErrorResponse(c *gin.Context, err error) {
+	status, code := errorStatus(err)
+	if status >= http.StatusInternalServerError {
+		logger.Error(err)
+	} else {
+		logger.Warn(err)
+	}
+
+	response := errorResponse{Code: code, Error: err.Error()}
+	if message, ok := genericMessages[code]; ok {
+		response.Error = message
+	}
+	var validationErr *entity.ValidationError
+	if errors.As(err, &validationErr) {
+		response.Fields = validationErr.Fields
+	}
+	c.AbortWithStatusJSON(status, response)
 }
@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{name: "bad request", err: badRequest("empty"), status: http.StatusBadRequest, code: codeBadRequest},
+		{name: "not found", err: usecase.ErrNotFound, status: http.StatusNotFound, code: codeNotFound},
+		{
+			name:   "already exists",
+			err:    usecase.NewError(usecase.ErrAlreadyExists, errors.New("duplicate")),
+			status: http.StatusConflict,
+			code:   codeAlreadyExists,
+		},
+		{
+			name:   "conflict",
+			err:    fmt.Errorf("wrapped: %w", usecase.NewError(usecase.ErrConflict, errors.New("stale"))),
+			status: http.StatusConflict,
+			code:   codeConflict,
+		},
+		{
+			name:   "invalid",
+			err:    usecase.NewError(usecase.ErrInvalid, &entity.ValidationError{}),
+			status: http.StatusUnprocessableEntity,
+			code:   codeInvalid,
+		},
+		{
+			name:   "unavailable",
+			err:    usecase.NewError(usecase.ErrUnavailable, errors.New("timeout")),
+			status: http.StatusServiceUnavailable,
+			code:   codeUnavailable,
+		},
+		{name: "internal", err: errors.New("some error"), status: http.StatusInternalServerError, code: codeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := errorStatus(tt.err)
+			require.Equal(t, tt.status, status)
+			require.Equal(t, tt.code, code)
+		})
+	}
+}
+
+func TestNewErrorResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		message string
+	}{
+		{
+			name:    "client error",
+			err:     badRequest("empty order_uid query param"),
+			message: "empty order_uid query param",
+		},
+		{
+			name:    "unavailable",
+			err:     usecase.NewError(usecase.ErrUnavailable, errors.New("dial tcp 10.0.0.1:5432: timeout")),
+			message: "service is temporarily unavailable",
+		},
+		{
+			name:    "internal",
+			err:     errors.New(`ERROR: relation "orders" does not exist`),
+			message: "internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			newErrorResponse(c, tt.err)
+
+			var response errorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			require.Equal(t, tt.message, response.Error)
+		})
+	}
+}
@@ -5,19 +5,47 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
 )
 
 type OrderUsecase interface {
-	GetOrderByID(context.Context, string) (*entity.Order, error)
+	GetOrderJSON(context.Context, string) ([]byte, int, error)
+	FindOrders(context.Context, entity.LookupField, string) ([]*entity.Order, error)
+	ListOrders(context.Context, usecase.OrderFilter, usecase.OrderPage) ([]*entity.Order, *usecase.OrderCursor, error)
+	SearchOrders(context.Context, string, int) ([]*entity.Order, error)
+	UpdateOrder(context.Context, *entity.Order, int) (*entity.Order, error)
+	CancelOrder(context.Context, string, string) (*entity.Order, error)
+	DeleteOrder(context.Context, string, bool) error
 }
 
 type Handler struct {
 	orderUsecase OrderUsecase
+	cursors      cursorCodec
 }
 
-func NewHandler(orderUsecase OrderUsecase) Handler {
+type Option func(*handlerConfig)
+
+type handlerConfig struct {
+	cursorKey []byte
+}
+
+// CursorKey sets the key signing the listing cursors. Replicas behind the same balancer need the same key.
+// Without it a random key is generated, so cursors are valid only within the process that issued them.
+func CursorKey(key []byte) Option {
+	return func(c *handlerConfig) {
+		c.cursorKey = key
+	}
+}
+
+func NewHandler(orderUsecase OrderUsecase, opts ...Option) Handler {
+	cfg := &handlerConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	return Handler{
 		orderUsecase: orderUsecase,
+		cursors:      newCursorCodec(cfg.cursorKey),
 	}
 }
 
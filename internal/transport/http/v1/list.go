@@ -0,0 +1,165 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+)
+
+// listFilterParams are the query params filtering the listing, a cursor is only valid for the same values of them.
+var listFilterParams = []string{
+	"customer_id",
+	"delivery_service",
+	"entry",
+	"locale",
+	"created_from",
+	"created_to",
+	"provider",
+	"currency",
+	"min_amount",
+	"max_amount",
+	"brand",
+}
+
+type listOrdersResponse struct {
+	Orders     []*entity.Order `json:"orders"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// getOrders looks orders up when track_number, transaction or rid is given and lists them otherwise,
+// with customer_id taken as a filter of the listing.
+func (h Handler) getOrders(c *gin.Context) {
+	for _, field := range entity.LookupFields {
+		if _, ok := c.GetQuery(string(field)); ok && field != entity.LookupCustomerID {
+			h.findOrders(c)
+			return
+		}
+	}
+	h.listOrders(c)
+}
+
+// listOrders returns a page of the orders matching the filter params, newest first. The next page
+// is requested with the next_cursor of the response passed as the cursor query param.
+func (h Handler) listOrders(c *gin.Context) {
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	filterKey := canonicalFilter(c)
+
+	var page usecase.OrderPage
+	if page.Limit, err = parseLimitParam(c); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	if token := c.Query("cursor"); token != "" {
+		if page.After, err = h.cursors.decode(token, filterKey); err != nil {
+			newErrorResponse(c, badRequest(fmt.Sprintf("%s, it does not match the filter or is damaged", err)))
+			return
+		}
+	}
+
+	orders, next, err := h.orderUsecase.ListOrders(c.Request.Context(), filter, page)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	response := listOrdersResponse{Orders: orders}
+	if response.Orders == nil {
+		response.Orders = []*entity.Order{}
+	}
+	if next != nil {
+		if response.NextCursor, err = h.cursors.encode(next, filterKey); err != nil {
+			newErrorResponse(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func parseOrderFilter(c *gin.Context) (usecase.OrderFilter, error) {
+	filter := usecase.OrderFilter{
+		CustomerID:      c.Query("customer_id"),
+		DeliveryService: c.Query("delivery_service"),
+		Entry:           c.Query("entry"),
+		Locale:          c.Query("locale"),
+		Provider:        c.Query("provider"),
+		Currency:        entity.Currency(c.Query("currency")),
+		Brand:           c.Query("brand"),
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseTimeParam(c, "created_from"); err != nil {
+		return usecase.OrderFilter{}, err
+	}
+	if filter.CreatedTo, err = parseTimeParam(c, "created_to"); err != nil {
+		return usecase.OrderFilter{}, err
+	}
+	if filter.MinAmount, err = parseAmountParam(c, "min_amount"); err != nil {
+		return usecase.OrderFilter{}, err
+	}
+	if filter.MaxAmount, err = parseAmountParam(c, "max_amount"); err != nil {
+		return usecase.OrderFilter{}, err
+	}
+	return filter, nil
+}
+
+// parseLimitParam reads the number of orders to return, zero means the default one.
+func parseLimitParam(c *gin.Context) (int, error) {
+	value := c.Query("limit")
+	if value == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return 0, badRequest(fmt.Sprintf("invalid limit query param %q", value))
+	}
+	return limit, nil
+}
+
+func parseTimeParam(c *gin.Context, param string) (time.Time, error) {
+	value := c.Query(param)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, badRequest(fmt.Sprintf("invalid %s query param %q, expected RFC 3339 time", param, value))
+	}
+	return t, nil
+}
+
+// parseAmountParam reads an amount in minor units, the way Money is encoded.
+func parseAmountParam(c *gin.Context, param string) (*int64, error) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, badRequest(fmt.Sprintf("invalid %s query param %q, expected minor units", param, value))
+	}
+	return &amount, nil
+}
+
+// canonicalFilter encodes the filter params of the request independently of their order in the query.
+func canonicalFilter(c *gin.Context) string {
+	values := url.Values{}
+	for _, param := range listFilterParams {
+		if value := c.Query(param); value != "" {
+			values.Set(param, value)
+		}
+	}
+	return values.Encode()
+}
@@ -0,0 +1,154 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_listOrders(t *testing.T) {
+	order := &entity.Order{OrderUID: "uid", DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)}
+	next := &usecase.OrderCursor{DateCreated: order.DateCreated, OrderUID: order.OrderUID}
+	minAmount := int64(100)
+
+	tests := []struct {
+		name   string
+		query  string
+		stub   stubOrderUsecase
+		status int
+		code   string
+		filter usecase.OrderFilter
+		page   usecase.OrderPage
+		orders int
+		next   bool
+	}{
+		{
+			name:   "no params",
+			status: http.StatusOK,
+		},
+		{
+			name:   "customer with next page",
+			query:  "?customer_id=test&limit=1",
+			stub:   stubOrderUsecase{order: order, next: next},
+			status: http.StatusOK,
+			filter: usecase.OrderFilter{CustomerID: "test"},
+			page:   usecase.OrderPage{Limit: 1},
+			orders: 1,
+			next:   true,
+		},
+		{
+			name: "all filters",
+			query: "?delivery_service=meest&entry=WBIL&locale=en&created_from=2021-11-01T00:00:00Z" +
+				"&created_to=2021-12-01T00:00:00Z&provider=wbpay&currency=USD&min_amount=100&brand=Vivienne+Sabo",
+			status: http.StatusOK,
+			filter: usecase.OrderFilter{
+				DeliveryService: "meest",
+				Entry:           "WBIL",
+				Locale:          "en",
+				CreatedFrom:     time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC),
+				CreatedTo:       time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC),
+				Provider:        "wbpay",
+				Currency:        "USD",
+				MinAmount:       &minAmount,
+				Brand:           "Vivienne Sabo",
+			},
+		},
+		{
+			name:   "invalid date",
+			query:  "?created_from=yesterday",
+			status: http.StatusBadRequest,
+			code:   codeBadRequest,
+		},
+		{
+			name:   "invalid amount",
+			query:  "?max_amount=1.5",
+			status: http.StatusBadRequest,
+			code:   codeBadRequest,
+		},
+		{
+			name:   "invalid limit",
+			query:  "?limit=0",
+			status: http.StatusBadRequest,
+			code:   codeBadRequest,
+		},
+		{
+			name:   "forged cursor",
+			query:  "?cursor=eyJ1IjoidWlkIn0.AAAA",
+			status: http.StatusBadRequest,
+			code:   codeBadRequest,
+		},
+		{
+			name:   "unavailable",
+			stub:   stubOrderUsecase{err: usecase.NewError(usecase.ErrUnavailable, errors.New("timeout"))},
+			status: http.StatusServiceUnavailable,
+			code:   codeUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.stub.listed = &listedPage{}
+			router := newTestRouter(tt.stub)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/orders"+tt.query, nil)
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.status, w.Code)
+			if tt.code != "" {
+				var response errorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				require.Equal(t, tt.code, response.Code)
+				return
+			}
+			require.Equal(t, tt.filter, tt.stub.listed.filter)
+			require.Equal(t, tt.page, tt.stub.listed.page)
+
+			var response listOrdersResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			require.NotNil(t, response.Orders)
+			require.Len(t, response.Orders, tt.orders)
+			require.Equal(t, tt.next, response.NextCursor != "")
+		})
+	}
+}
+
+func TestHandler_listOrdersNextPage(t *testing.T) {
+	stub := stubOrderUsecase{
+		order:  &entity.Order{OrderUID: "uid"},
+		next:   &usecase.OrderCursor{DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC), OrderUID: "uid"},
+		listed: &listedPage{},
+	}
+	router := newTestRouter(stub)
+
+	get := func(query url.Values) (int, listOrdersResponse) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/orders?"+query.Encode(), nil))
+
+		var response listOrdersResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w.Code, response
+	}
+
+	status, first := get(url.Values{"customer_id": {"test"}, "locale": {"en"}})
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, first.NextCursor)
+
+	// the order of the params doesn't matter, the filter does
+	status, _ = get(url.Values{"locale": {"en"}, "customer_id": {"test"}, "cursor": {first.NextCursor}})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, stub.next, stub.listed.page.After)
+
+	status, _ = get(url.Values{"customer_id": {"other"}, "locale": {"en"}, "cursor": {first.NextCursor}})
+	require.Equal(t, http.StatusBadRequest, status)
+}
@@ -1,25 +1,168 @@
 package v1
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/maypok86/wb-l0/internal/entity"
 )
 
+const jsonContentType = "application/json; charset=utf-8"
+
 func (h Handler) newOrderRoutes(v1 *gin.RouterGroup) {
 	v1.GET("/order", h.getOrderByID)
+	v1.GET("/orders", h.getOrders)
+	v1.GET("/orders/search", h.searchOrders)
+	v1.PUT("/orders/:uid", h.updateOrder)
+	v1.POST("/orders/:uid/cancel", h.cancelOrder)
+	v1.DELETE("/orders/:uid", h.deleteOrder)
 }
 
 func (h Handler) getOrderByID(c *gin.Context) {
 	orderUID := c.Query("order_uid")
 	if orderUID == "" {
-		newErrorResponse(c, http.StatusBadRequest, "empty order_uid query param")
+		newErrorResponse(c, badRequest("empty order_uid query param"))
+		return
+	}
+	// the cached encoding is written as is, it is what c.JSON would produce for the order
+	data, version, err := h.orderUsecase.GetOrderJSON(c.Request.Context(), orderUID)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.Header("ETag", versionETag(version))
+	c.Data(http.StatusOK, jsonContentType, data)
+}
+
+// findOrders looks orders up by exactly one of the lookup fields passed as a query param.
+func (h Handler) findOrders(c *gin.Context) {
+	var (
+		field entity.LookupField
+		value string
+	)
+	for _, lookupField := range entity.LookupFields {
+		lookupValue, ok := c.GetQuery(string(lookupField))
+		if !ok {
+			continue
+		}
+		if field != "" {
+			newErrorResponse(c, badRequest(fmt.Sprintf("only one of %s query params is allowed", lookupParams())))
+			return
+		}
+		field, value = lookupField, lookupValue
+	}
+	if value == "" {
+		newErrorResponse(c, badRequest(fmt.Sprintf("one of %s query params is required", lookupParams())))
+		return
+	}
+
+	orders, err := h.orderUsecase.FindOrders(c.Request.Context(), field, value)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, orders)
+}
+
+func lookupParams() string {
+	params := make([]string, 0, len(entity.LookupFields))
+	for _, field := range entity.LookupFields {
+		params = append(params, string(field))
+	}
+	return strings.Join(params, ", ")
+}
+
+func (h Handler) updateOrder(c *gin.Context) {
+	orderUID := c.Param("uid")
+
+	order := &entity.Order{}
+	if err := c.ShouldBindJSON(order); err != nil {
+		newErrorResponse(c, badRequest(fmt.Sprintf("can not parse order: %s", err)))
+		return
+	}
+	if order.OrderUID == "" {
+		order.OrderUID = orderUID
+	}
+	if order.OrderUID != orderUID {
+		newErrorResponse(c, badRequest("order_uid in body does not match the path"))
+		return
+	}
+
+	// If-Match takes precedence over the version in the body.
+	version := order.Version
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		var err error
+		if version, err = parseVersionETag(ifMatch); err != nil {
+			newErrorResponse(c, badRequest(err.Error()))
+			return
+		}
+	}
+	if version <= 0 {
+		newErrorResponse(c, preconditionRequired("If-Match header or version in body is required"))
 		return
 	}
-	order, err := h.orderUsecase.GetOrderByID(c.Request.Context(), orderUID)
+
+	order, err := h.orderUsecase.UpdateOrder(c.Request.Context(), order, version)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newErrorResponse(c, err)
 		return
 	}
+	c.Header("ETag", versionETag(order.Version))
 	c.JSON(http.StatusOK, order)
 }
+
+type cancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h Handler) cancelOrder(c *gin.Context) {
+	request := cancelOrderRequest{Reason: "cancelled via api"}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			newErrorResponse(c, badRequest(fmt.Sprintf("can not parse cancel request: %s", err)))
+			return
+		}
+	}
+
+	order, err := h.orderUsecase.CancelOrder(c.Request.Context(), c.Param("uid"), request.Reason)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.Header("ETag", versionETag(order.Version))
+	c.JSON(http.StatusOK, order)
+}
+
+func (h Handler) deleteOrder(c *gin.Context) {
+	purge := false
+	if value := c.Query("purge"); value != "" {
+		var err error
+		if purge, err = strconv.ParseBool(value); err != nil {
+			newErrorResponse(c, badRequest(fmt.Sprintf("invalid purge query param %q", value)))
+			return
+		}
+	}
+
+	if err := h.orderUsecase.DeleteOrder(c.Request.Context(), c.Param("uid"), purge); err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func versionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseVersionETag reads the order version from an If-Match value produced by versionETag.
+func parseVersionETag(etag string) (int, error) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	version, err := strconv.Atoi(strings.Trim(etag, `"`))
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("invalid If-Match header %q, expected the order version", etag)
+	}
+	return version, nil
+}
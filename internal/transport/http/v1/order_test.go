@@ -0,0 +1,497 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maypok86/wb-l0/internal/cache"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/maypok86/wb-l0/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("fatal")
+	os.Exit(m.Run())
+}
+
+type stubOrderUsecase struct {
+	order *entity.Order
+	next  *usecase.OrderCursor
+	err   error
+	// listed records the arguments of ListOrders when set
+	listed *listedPage
+}
+
+type listedPage struct {
+	filter usecase.OrderFilter
+	page   usecase.OrderPage
+}
+
+func (s stubOrderUsecase) GetOrderJSON(context.Context, string) ([]byte, int, error) {
+	if s.err != nil {
+		return nil, 0, s.err
+	}
+	data, err := json.Marshal(s.order)
+	return data, s.order.Version, err
+}
+
+func (s stubOrderUsecase) FindOrders(context.Context, entity.LookupField, string) ([]*entity.Order, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.order == nil {
+		return []*entity.Order{}, nil
+	}
+	return []*entity.Order{s.order}, nil
+}
+
+func (s stubOrderUsecase) ListOrders(
+	_ context.Context,
+	filter usecase.OrderFilter,
+	page usecase.OrderPage,
+) ([]*entity.Order, *usecase.OrderCursor, error) {
+	if s.listed != nil {
+		*s.listed = listedPage{filter: filter, page: page}
+	}
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	if s.order == nil {
+		return nil, nil, nil
+	}
+	return []*entity.Order{s.order}, s.next, nil
+}
+
+func (s stubOrderUsecase) SearchOrders(context.Context, string, int) ([]*entity.Order, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.order == nil {
+		return nil, nil
+	}
+	return []*entity.Order{s.order}, nil
+}
+
+func (s stubOrderUsecase) UpdateOrder(_ context.Context, order *entity.Order, version int) (*entity.Order, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	order.Version = version + 1
+	return order, nil
+}
+
+func (s stubOrderUsecase) CancelOrder(context.Context, string, string) (*entity.Order, error) {
+	return s.order, s.err
+}
+
+func (s stubOrderUsecase) DeleteOrder(context.Context, string, bool) error {
+	return s.err
+}
+
+func newTestRouter(orderUsecase OrderUsecase) *gin.Engine {
+	router := gin.New()
+	NewHandler(orderUsecase).Register(router.Group("/api"))
+	return router
+}
+
+func TestHandler_getOrderByID(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		stub   stubOrderUsecase
+		status int
+		code   string
+		etag   string
+	}{
+		{
+			name:   "empty order_uid",
+			query:  "",
+			status: http.StatusBadRequest,
+			code:   codeBadRequest,
+		},
+		{
+			name:   "found",
+			query:  "?order_uid=uid",
+			stub:   stubOrderUsecase{order: &entity.Order{OrderUID: "uid", Version: 3}},
+			status: http.StatusOK,
+			etag:   `"3"`,
+		},
+		{
+			name:   "not found",
+			query:  "?order_uid=uid",
+			stub:   stubOrderUsecase{err: fmt.Errorf("can not get order by id: %w", usecase.ErrNotFound)},
+			status: http.StatusNotFound,
+			code:   codeNotFound,
+		},
+		{
+			name:   "unavailable",
+			query:  "?order_uid=uid",
+			stub:   stubOrderUsecase{err: usecase.NewError(usecase.ErrUnavailable, errors.New("timeout"))},
+			status: http.StatusServiceUnavailable,
+			code:   codeUnavailable,
+		},
+		{
+			name:   "internal",
+			query:  "?order_uid=uid",
+			stub:   stubOrderUsecase{err: errors.New("some error")},
+			status: http.StatusInternalServerError,
+			code:   codeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(tt.stub)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/order"+tt.query, nil)
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.status, w.Code)
+			if tt.etag != "" {
+				require.Equal(t, tt.etag, w.Header().Get("ETag"))
+				require.Equal(t, jsonContentType, w.Header().Get("Content-Type"))
+				var order entity.Order
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+				require.Equal(t, *tt.stub.order, order)
+			}
+			if tt.code != "" {
+				var response errorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				require.Equal(t, tt.code, response.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_findOrders(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		stub   stubOrderUsecase
+		status int
+		code   string
+		orders int
+	}{
+		{
+			name:   "empty lookup param",
+			query:  "?track_number=",
+			status: http.StatusBadRequest,
+			code:   codeBadRequest,
+		},
+		{
+			name:   "several lookup params",
+			query:  "?track_number=TRACK&customer_id=test",
+			status: http.StatusBadRequest,
+			code:   codeBadRequest,
+		},
+		{
+			name:   "found by track number",
+			query:  "?track_number=TRACK",
+			stub:   stubOrderUsecase{order: &entity.Order{OrderUID: "uid", TrackNumber: "TRACK"}},
+			status: http.StatusOK,
+			orders: 1,
+		},
+		{
+			name:   "nothing found by rid",
+			query:  "?rid=rid",
+			status: http.StatusOK,
+			orders: 0,
+		},
+		{
+			name:   "internal",
+			query:  "?rid=rid",
+			stub:   stubOrderUsecase{err: errors.New("some error")},
+			status: http.StatusInternalServerError,
+			code:   codeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(tt.stub)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/orders"+tt.query, nil)
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.status, w.Code)
+			if tt.code != "" {
+				var response errorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				require.Equal(t, tt.code, response.Code)
+				return
+			}
+			var orders []*entity.Order
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &orders))
+			require.Len(t, orders, tt.orders)
+		})
+	}
+}
+
+func TestHandler_updateOrder(t *testing.T) {
+	const body = `{"order_uid":"uid","track_number":"TRACK"}`
+
+	tests := []struct {
+		name    string
+		path    string
+		ifMatch string
+		body    string
+		stub    stubOrderUsecase
+		status  int
+		code    string
+		etag    string
+	}{
+		{
+			name:    "updated",
+			path:    "/api/v1/orders/uid",
+			ifMatch: `"3"`,
+			body:    body,
+			status:  http.StatusOK,
+			etag:    `"4"`,
+		},
+		{
+			name:    "weak etag",
+			path:    "/api/v1/orders/uid",
+			ifMatch: `W/"3"`,
+			body:    `{"track_number":"TRACK"}`,
+			status:  http.StatusOK,
+			etag:    `"4"`,
+		},
+		{
+			name:   "version in body",
+			path:   "/api/v1/orders/uid",
+			body:   `{"order_uid":"uid","version":7}`,
+			status: http.StatusOK,
+			etag:   `"8"`,
+		},
+		{
+			name:   "missing if-match",
+			path:   "/api/v1/orders/uid",
+			body:   body,
+			status: http.StatusPreconditionRequired,
+			code:   codePreconditionRequired,
+		},
+		{
+			name:    "malformed if-match",
+			path:    "/api/v1/orders/uid",
+			ifMatch: `"abc"`,
+			body:    body,
+			status:  http.StatusBadRequest,
+			code:    codeBadRequest,
+		},
+		{
+			name:    "uid mismatch",
+			path:    "/api/v1/orders/other",
+			ifMatch: `"3"`,
+			body:    body,
+			status:  http.StatusBadRequest,
+			code:    codeBadRequest,
+		},
+		{
+			name:    "malformed body",
+			path:    "/api/v1/orders/uid",
+			ifMatch: `"3"`,
+			body:    `{`,
+			status:  http.StatusBadRequest,
+			code:    codeBadRequest,
+		},
+		{
+			name:    "version mismatch",
+			path:    "/api/v1/orders/uid",
+			ifMatch: `"3"`,
+			body:    body,
+			stub: stubOrderUsecase{err: usecase.NewError(
+				usecase.ErrConflict,
+				fmt.Errorf("can not update order uid: %w", usecase.ErrVersionMismatch),
+			)},
+			status: http.StatusPreconditionFailed,
+			code:   codePreconditionFailed,
+		},
+		{
+			name:    "invalid order",
+			path:    "/api/v1/orders/uid",
+			ifMatch: `"3"`,
+			body:    body,
+			stub:    stubOrderUsecase{err: usecase.NewError(usecase.ErrInvalid, &entity.ValidationError{})},
+			status:  http.StatusUnprocessableEntity,
+			code:    codeInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(tt.stub)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.status, w.Code)
+			if tt.etag != "" {
+				require.Equal(t, tt.etag, w.Header().Get("ETag"))
+			}
+			if tt.code != "" {
+				var response errorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				require.Equal(t, tt.code, response.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_cancelOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		stub   stubOrderUsecase
+		status int
+		code   string
+	}{
+		{
+			name:   "cancelled",
+			stub:   stubOrderUsecase{order: &entity.Order{OrderUID: "uid", Status: entity.StatusCancelled, Version: 2}},
+			status: http.StatusOK,
+		},
+		{
+			name:   "cancelled with reason",
+			body:   `{"reason":"customer request"}`,
+			stub:   stubOrderUsecase{order: &entity.Order{OrderUID: "uid", Status: entity.StatusCancelled, Version: 2}},
+			status: http.StatusOK,
+		},
+		{
+			name:   "malformed body",
+			body:   `{`,
+			status: http.StatusBadRequest,
+			code:   codeBadRequest,
+		},
+		{
+			name: "illegal transition",
+			stub: stubOrderUsecase{err: usecase.NewError(
+				usecase.ErrConflict,
+				fmt.Errorf("%w: from delivered to cancelled", usecase.ErrIllegalTransition),
+			)},
+			status: http.StatusConflict,
+			code:   codeConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(tt.stub)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/orders/uid/cancel", strings.NewReader(tt.body))
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.status, w.Code)
+			if tt.code != "" {
+				var response errorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				require.Equal(t, tt.code, response.Code)
+			}
+		})
+	}
+}
+
+func TestHandler_deleteOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		stub   stubOrderUsecase
+		status int
+	}{
+		{
+			name:   "deleted",
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "purged",
+			query:  "?purge=true",
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "invalid purge",
+			query:  "?purge=maybe",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "not found",
+			stub:   stubOrderUsecase{err: fmt.Errorf("can not delete in repository: %w", usecase.ErrNotFound)},
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(tt.stub)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/orders/uid"+tt.query, nil)
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+// BenchmarkHandler_getOrderByID compares encoding the cached order on every request
+// with writing the encoding kept by the cache.
+func BenchmarkHandler_getOrderByID(b *testing.B) {
+	data, err := os.ReadFile("../../../../docs/model.json")
+	require.NoError(b, err)
+	order := &entity.Order{}
+	require.NoError(b, json.Unmarshal(data, order))
+
+	memoryCache := cache.NewMemoryCache(cache.EncodeJSON(true))
+	defer memoryCache.Close()
+	require.NoError(b, memoryCache.Set(order.OrderUID, order, 0))
+	orderUsecase := usecase.NewOrderUsecase(memoryCache, nil)
+
+	router := newTestRouter(orderUsecase)
+	router.GET("/encode", func(c *gin.Context) {
+		order, err := orderUsecase.GetOrderByID(c.Request.Context(), c.Query("order_uid"))
+		if err != nil {
+			newErrorResponse(c, err)
+			return
+		}
+		c.Header("ETag", versionETag(order.Version))
+		c.JSON(http.StatusOK, order)
+	})
+
+	for _, bm := range []struct {
+		name string
+		path string
+	}{
+		{name: "encode", path: "/encode"},
+		{name: "encoded", path: "/api/v1/order"},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			req := httptest.NewRequest(http.MethodGet, bm.path+"?order_uid="+order.OrderUID, nil)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					b.Fatalf("unexpected status %d", w.Code)
+				}
+			}
+		})
+	}
+}
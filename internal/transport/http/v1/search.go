@@ -0,0 +1,33 @@
+package v1
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+// searchOrders looks for orders by the names and brands of their items, the best matches first.
+func (h Handler) searchOrders(c *gin.Context) {
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		newErrorResponse(c, badRequest("empty q query param"))
+		return
+	}
+	limit, err := parseLimitParam(c)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+
+	orders, err := h.orderUsecase.SearchOrders(c.Request.Context(), query, limit)
+	if err != nil {
+		newErrorResponse(c, err)
+		return
+	}
+	if orders == nil {
+		orders = []*entity.Order{}
+	}
+	c.JSON(http.StatusOK, orders)
+}
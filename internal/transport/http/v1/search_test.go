@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_searchOrders(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		stub   stubOrderUsecase
+		status int
+		code   string
+		orders int
+	}{
+		{
+			name:   "no query",
+			status: http.StatusBadRequest,
+			code:   codeBadRequest,
+		},
+		{
+			name:   "blank query",
+			query:  "?q=+",
+			status: http.StatusBadRequest,
+			code:   codeBadRequest,
+		},
+		{
+			name:   "invalid limit",
+			query:  "?q=mascaras&limit=-1",
+			status: http.StatusBadRequest,
+			code:   codeBadRequest,
+		},
+		{
+			name:   "found",
+			query:  "?q=mascaras&limit=10",
+			stub:   stubOrderUsecase{order: &entity.Order{OrderUID: "uid"}},
+			status: http.StatusOK,
+			orders: 1,
+		},
+		{
+			name:   "nothing found",
+			query:  "?q=mascaras",
+			status: http.StatusOK,
+		},
+		{
+			name:   "unavailable",
+			query:  "?q=mascaras",
+			stub:   stubOrderUsecase{err: usecase.NewError(usecase.ErrUnavailable, errors.New("timeout"))},
+			status: http.StatusServiceUnavailable,
+			code:   codeUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(tt.stub)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/orders/search"+tt.query, nil)
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.status, w.Code)
+			if tt.code != "" {
+				var response errorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				require.Equal(t, tt.code, response.Code)
+				return
+			}
+			var orders []*entity.Order
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &orders))
+			require.NotNil(t, orders)
+			require.Len(t, orders, tt.orders)
+		})
+	}
+}
@@ -3,26 +3,58 @@ package stan
 import (
 	"context"
 	"encoding/json"
+	"expvar"
 	"fmt"
-	"time"
 
 	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
 	"github.com/maypok86/wb-l0/pkg/logger"
 	"github.com/nats-io/stan.go"
 )
 
+const (
+	outcomeMalformed = "malformed"
+	outcomeInvalid   = "invalid"
+	outcomeFailed    = "failed"
+)
+
+// ingestedOrders counts received order messages by outcome, it is exported through expvar.
+var ingestedOrders = expvar.NewMap("ingested_orders")
+
 func (r Router) newOrder(ctx context.Context) error {
 	return r.natsStreaming.Subscribe("orders", func(msg *stan.Msg) {
 		order := &entity.Order{}
 		if err := json.Unmarshal(msg.Data, order); err != nil {
+			ingestedOrders.Add(outcomeMalformed, 1)
 			logger.Error(fmt.Errorf("can not unmarshal order: %w", err))
 			return
 		}
-		_, err := r.orderUsecase.CreateOrder(ctx, order, time.Hour)
-		if err != nil {
-			logger.Error(fmt.Errorf("can not create order: %w", err))
+		if err := order.Validate(); err != nil {
+			ingestedOrders.Add(outcomeInvalid, 1)
+			logger.Warn(fmt.Errorf("reject order: %w", err))
 			return
 		}
-		logger.Info("successful create order")
+
+		_, outcome, err := r.orderUsecase.CreateOrder(ctx, order, r.cacheTTL)
+		if outcome == "" {
+			ingestedOrders.Add(outcomeFailed, 1)
+		} else {
+			ingestedOrders.Add(string(outcome), 1)
+		}
+
+		switch outcome {
+		case usecase.OutcomeCreated:
+			logger.Infof("order %s created", order.OrderUID)
+		case usecase.OutcomeDuplicate:
+			logger.Infof("order %s redelivered, skip exact duplicate", order.OrderUID)
+		case usecase.OutcomeOverwritten:
+			logger.Warnf("order %s redelivered with different content, stored order overwritten", order.OrderUID)
+		case usecase.OutcomeFlagged:
+			logger.Warnf("order %s redelivered with different content, stored order kept and duplicate flagged", order.OrderUID)
+		case usecase.OutcomeRejected:
+			logger.Errorf("order %s redelivered with different content, rejected: %v", order.OrderUID, err)
+		default:
+			logger.Error(fmt.Errorf("can not create order: %w", err))
+		}
 	})
 }
@@ -5,22 +5,25 @@ import (
 	"time"
 
 	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
 	"github.com/maypok86/wb-l0/pkg/nats"
 )
 
 type OrderUsecase interface {
-	CreateOrder(context.Context, *entity.Order, time.Duration) (*entity.Order, error)
+	CreateOrder(context.Context, *entity.Order, time.Duration) (*entity.Order, usecase.CreateOutcome, error)
 }
 
 type Router struct {
 	natsStreaming *nats.Streaming
 	orderUsecase  OrderUsecase
+	cacheTTL      time.Duration
 }
 
-func NewRouter(natsStreaming *nats.Streaming, orderUsecase OrderUsecase) Router {
+func NewRouter(natsStreaming *nats.Streaming, orderUsecase OrderUsecase, cacheTTL time.Duration) Router {
 	return Router{
 		natsStreaming: natsStreaming,
 		orderUsecase:  orderUsecase,
+		cacheTTL:      cacheTTL,
 	}
 }
 
@@ -0,0 +1,22 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+)
+
+// DeleteOrder soft-deletes the order, or removes it with all related rows if purge is set,
+// and evicts it from the cache.
+func (ou OrderUsecase) DeleteOrder(ctx context.Context, orderUID string, purge bool) error {
+	deleteOrder := ou.repository.DeleteOrder
+	if purge {
+		deleteOrder = ou.repository.PurgeOrder
+	}
+	if err := deleteOrder(ctx, orderUID); err != nil {
+		return fmt.Errorf("can not delete in repository: %w", err)
+	}
+
+	ou.missing.add(orderUID)
+	ou.evictStored(orderUID)
+	return nil
+}
@@ -0,0 +1,81 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderUsecase_DeleteOrder(t *testing.T) {
+	order, cache, repo := mockOrderUsecase(t)
+	orderUID := validOrder(t).OrderUID
+
+	tests := []struct {
+		name  string
+		mock  func()
+		purge bool
+		err   error
+	}{
+		{
+			name: "error in repo",
+			mock: func() {
+				repo.EXPECT().DeleteOrder(context.Background(), orderUID).Return(errors.New("some error"))
+			},
+			err: errors.New("can not delete in repository: some error"),
+		},
+		{
+			name: "error in cache",
+			mock: func() {
+				repo.EXPECT().DeleteOrder(context.Background(), orderUID).Return(nil)
+				cache.EXPECT().Delete(orderUID).Return(errors.New("some error"))
+			},
+			err: nil,
+		},
+		{
+			name: "soft delete",
+			mock: func() {
+				repo.EXPECT().DeleteOrder(context.Background(), orderUID).Return(nil)
+				cache.EXPECT().Delete(orderUID).Return(nil)
+			},
+			err: nil,
+		},
+		{
+			name: "purge",
+			mock: func() {
+				repo.EXPECT().PurgeOrder(context.Background(), orderUID).Return(nil)
+				cache.EXPECT().Delete(orderUID).Return(nil)
+			},
+			purge: true,
+			err:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := order.DeleteOrder(context.Background(), orderUID, tt.purge)
+			if tt.err != nil {
+				require.Equal(t, tt.err.Error(), err.Error())
+			} else {
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestOrderUsecase_GetDeletedOrder(t *testing.T) {
+	order, cache, repo := mockOrderUsecase(t)
+	orderUID := validOrder(t).OrderUID
+
+	repo.EXPECT().DeleteOrder(context.Background(), orderUID).Return(nil)
+	cache.EXPECT().Delete(orderUID).Return(nil)
+	require.NoError(t, order.DeleteOrder(context.Background(), orderUID, false))
+
+	cache.EXPECT().Get(orderUID).Return(nil, errors.New("not found"))
+	_, err := order.GetOrderByID(context.Background(), orderUID)
+	require.True(t, errors.Is(err, usecase.ErrNotFound))
+}
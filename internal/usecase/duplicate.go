@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+// DuplicatePolicy decides what happens with a redelivered order whose content differs from the stored one.
+type DuplicatePolicy string
+
+const (
+	// DuplicateReject keeps the stored order and fails the redelivered one with ErrConflict.
+	DuplicateReject DuplicatePolicy = "reject"
+	// DuplicateOverwrite replaces the stored order with the redelivered one.
+	DuplicateOverwrite DuplicatePolicy = "overwrite"
+	// DuplicateKeepFirst keeps the stored order and records the redelivered one for review.
+	DuplicateKeepFirst DuplicatePolicy = "keep-first"
+)
+
+func ParseDuplicatePolicy(policy string) (DuplicatePolicy, error) {
+	switch p := DuplicatePolicy(policy); p {
+	case DuplicateReject, DuplicateOverwrite, DuplicateKeepFirst:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown duplicate policy %q", policy)
+	}
+}
+
+// CreateOutcome tells what CreateOrder did with the order.
+type CreateOutcome string
+
+const (
+	OutcomeCreated     CreateOutcome = "created"
+	OutcomeDuplicate   CreateOutcome = "duplicate"
+	OutcomeRejected    CreateOutcome = "rejected"
+	OutcomeOverwritten CreateOutcome = "overwritten"
+	OutcomeFlagged     CreateOutcome = "flagged"
+)
+
+func (ou OrderUsecase) createDuplicate(
+	ctx context.Context,
+	order *entity.Order,
+	ttl time.Duration,
+	createErr error,
+) (*entity.Order, CreateOutcome, error) {
+	stored, err := ou.repository.GetOrderByID(ctx, order.OrderUID)
+	if errors.Is(err, ErrNotFound) {
+		// the conflict is not on the order uid, e.g. another order has the same track number
+		return nil, "", fmt.Errorf("can not create in repository: %w", createErr)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("can not get stored order: %w", err)
+	}
+
+	if stored.EqualContent(order) {
+		ou.cacheStored(stored, ttl)
+		return stored, OutcomeDuplicate, nil
+	}
+
+	switch ou.duplicatePolicy {
+	case DuplicateOverwrite:
+		overwritten, err := ou.repository.OverwriteOrder(ctx, order)
+		if err != nil {
+			return nil, "", fmt.Errorf("can not overwrite order in repository: %w", err)
+		}
+		ou.cacheStored(overwritten, ttl)
+		return overwritten, OutcomeOverwritten, nil
+	case DuplicateKeepFirst:
+		if err := ou.repository.FlagDuplicate(ctx, order); err != nil {
+			return nil, "", fmt.Errorf("can not flag duplicate order in repository: %w", err)
+		}
+		ou.cacheStored(stored, ttl)
+		return stored, OutcomeFlagged, nil
+	default:
+		return nil, OutcomeRejected, NewError(
+			ErrConflict,
+			fmt.Errorf("order %s already exists with different content", order.OrderUID),
+		)
+	}
+}
@@ -0,0 +1,161 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuplicatePolicy(t *testing.T) {
+	for _, policy := range []usecase.DuplicatePolicy{
+		usecase.DuplicateReject,
+		usecase.DuplicateOverwrite,
+		usecase.DuplicateKeepFirst,
+	} {
+		parsed, err := usecase.ParseDuplicatePolicy(string(policy))
+		require.NoError(t, err)
+		require.Equal(t, policy, parsed)
+	}
+
+	_, err := usecase.ParseDuplicatePolicy("ignore")
+	require.Error(t, err)
+}
+
+func TestOrderUsecase_CreateOrderDuplicate(t *testing.T) {
+	ttl := fakeTTL(t)
+	alreadyExists := usecase.NewError(usecase.ErrAlreadyExists, errors.New("duplicate key"))
+
+	stored := validOrder(t)
+	stored.Status = entity.StatusPaid
+
+	tests := []struct {
+		name    string
+		policy  usecase.DuplicatePolicy
+		modify  func(*entity.Order)
+		mock    func(*MockOrderCache, *MockOrderRepository, *entity.Order)
+		result  *entity.Order
+		outcome usecase.CreateOutcome
+		err     error
+	}{
+		{
+			name:   "exact duplicate",
+			policy: usecase.DuplicateReject,
+			modify: func(*entity.Order) {},
+			mock: func(cache *MockOrderCache, repo *MockOrderRepository, order *entity.Order) {
+				repo.EXPECT().CreateOrder(context.Background(), order).Return(nil, alreadyExists)
+				repo.EXPECT().GetOrderByID(context.Background(), order.OrderUID).Return(stored, nil)
+				cache.EXPECT().Set(stored.OrderUID, stored, ttl).Return(nil)
+			},
+			result:  stored,
+			outcome: usecase.OutcomeDuplicate,
+			err:     nil,
+		},
+		{
+			name:   "conflict on another key",
+			policy: usecase.DuplicateOverwrite,
+			modify: func(*entity.Order) {},
+			mock: func(cache *MockOrderCache, repo *MockOrderRepository, order *entity.Order) {
+				repo.EXPECT().CreateOrder(context.Background(), order).Return(nil, alreadyExists)
+				repo.EXPECT().GetOrderByID(context.Background(), order.OrderUID).Return(nil, usecase.ErrNotFound)
+			},
+			result:  nil,
+			outcome: "",
+			err:     errors.New("can not create in repository: duplicate key"),
+		},
+		{
+			name:   "reject",
+			policy: usecase.DuplicateReject,
+			modify: func(order *entity.Order) {
+				order.Delivery.Address = "Other street"
+			},
+			mock: func(cache *MockOrderCache, repo *MockOrderRepository, order *entity.Order) {
+				repo.EXPECT().CreateOrder(context.Background(), order).Return(nil, alreadyExists)
+				repo.EXPECT().GetOrderByID(context.Background(), order.OrderUID).Return(stored, nil)
+			},
+			result:  nil,
+			outcome: usecase.OutcomeRejected,
+			err:     errors.New("order b563feb7b2b84b6test already exists with different content"),
+		},
+		{
+			name:   "overwrite",
+			policy: usecase.DuplicateOverwrite,
+			modify: func(order *entity.Order) {
+				order.Delivery.Address = "Other street"
+			},
+			mock: func(cache *MockOrderCache, repo *MockOrderRepository, order *entity.Order) {
+				repo.EXPECT().CreateOrder(context.Background(), order).Return(nil, alreadyExists)
+				repo.EXPECT().GetOrderByID(context.Background(), order.OrderUID).Return(stored, nil)
+				repo.EXPECT().OverwriteOrder(context.Background(), order).Return(stored, nil)
+				cache.EXPECT().Set(stored.OrderUID, stored, ttl).Return(nil)
+			},
+			result:  stored,
+			outcome: usecase.OutcomeOverwritten,
+			err:     nil,
+		},
+		{
+			name:   "keep first",
+			policy: usecase.DuplicateKeepFirst,
+			modify: func(order *entity.Order) {
+				order.Delivery.Address = "Other street"
+			},
+			mock: func(cache *MockOrderCache, repo *MockOrderRepository, order *entity.Order) {
+				repo.EXPECT().CreateOrder(context.Background(), order).Return(nil, alreadyExists)
+				repo.EXPECT().GetOrderByID(context.Background(), order.OrderUID).Return(stored, nil)
+				repo.EXPECT().FlagDuplicate(context.Background(), order).Return(nil)
+				cache.EXPECT().Set(stored.OrderUID, stored, ttl).Return(nil)
+			},
+			result:  stored,
+			outcome: usecase.OutcomeFlagged,
+			err:     nil,
+		},
+		{
+			name:   "error in flag",
+			policy: usecase.DuplicateKeepFirst,
+			modify: func(order *entity.Order) {
+				order.Delivery.Address = "Other street"
+			},
+			mock: func(cache *MockOrderCache, repo *MockOrderRepository, order *entity.Order) {
+				repo.EXPECT().CreateOrder(context.Background(), order).Return(nil, alreadyExists)
+				repo.EXPECT().GetOrderByID(context.Background(), order.OrderUID).Return(stored, nil)
+				repo.EXPECT().FlagDuplicate(context.Background(), order).Return(errors.New("some error"))
+			},
+			result:  nil,
+			outcome: "",
+			err:     errors.New("can not flag duplicate order in repository: some error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, cache, repo := mockOrderUsecase(t, usecase.OnDuplicate(tt.policy))
+			redelivered := validOrder(t)
+			tt.modify(redelivered)
+			tt.mock(cache, repo, redelivered)
+
+			result, outcome, err := order.CreateOrder(context.Background(), redelivered, ttl)
+			require.Equal(t, tt.result, result)
+			require.Equal(t, tt.outcome, outcome)
+			if tt.err != nil {
+				require.Equal(t, tt.err.Error(), err.Error())
+			} else {
+				require.Nil(t, err)
+			}
+		})
+	}
+
+	t.Run("reject is a conflict", func(t *testing.T) {
+		order, _, repo := mockOrderUsecase(t)
+		redelivered := validOrder(t)
+		redelivered.Delivery.Address = "Other street"
+		repo.EXPECT().CreateOrder(context.Background(), gomock.Any()).Return(nil, alreadyExists)
+		repo.EXPECT().GetOrderByID(context.Background(), redelivered.OrderUID).Return(stored, nil)
+
+		_, _, err := order.CreateOrder(context.Background(), redelivered, ttl)
+		require.True(t, errors.Is(err, usecase.ErrConflict))
+	})
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// JSONOrderCache is an OrderCache able to return the JSON encoding of an order without decoding it first.
+// GetOrderJSON uses it when the cache implements it.
+type JSONOrderCache interface {
+	GetJSON(string) ([]byte, int, error)
+}
+
+// GetOrderJSON returns the JSON encoding of the order along with its version. Cache hits skip
+// encoding when the cache keeps it, misses are served like GetOrderByID.
+func (ou OrderUsecase) GetOrderJSON(ctx context.Context, orderUID string) ([]byte, int, error) {
+	if jsonCache, ok := ou.cache.(JSONOrderCache); ok {
+		if data, version, err := jsonCache.GetJSON(orderUID); err == nil {
+			return data, version, nil
+		}
+	}
+
+	order, err := ou.GetOrderByID(ctx, orderUID)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := json.Marshal(order)
+	if err != nil {
+		return nil, 0, fmt.Errorf("can not encode order: %w", err)
+	}
+	return data, order.Version, nil
+}
@@ -0,0 +1,60 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonOrderCache struct {
+	*MockOrderCache
+	encoded map[string][]byte
+}
+
+func (c jsonOrderCache) GetJSON(key string) ([]byte, int, error) {
+	data, ok := c.encoded[key]
+	if !ok {
+		return nil, 0, errors.New("cache error")
+	}
+	return data, 1, nil
+}
+
+func TestOrderUsecase_GetOrderJSON(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	orderEntity := validOrder(t)
+	orderEntity.Version = 2
+	encoded, err := json.Marshal(orderEntity)
+	require.NoError(t, err)
+
+	repo := NewMockOrderRepository(mockCtl)
+	cache := jsonOrderCache{MockOrderCache: NewMockOrderCache(mockCtl), encoded: map[string][]byte{"cached": []byte(`{}`)}}
+	ttl := fakeTTL(t)
+	order := usecase.NewOrderUsecase(cache, repo, usecase.CacheTTL(ttl))
+
+	data, version, err := order.GetOrderJSON(context.Background(), "cached")
+	require.NoError(t, err)
+	require.Equal(t, []byte(`{}`), data)
+	require.Equal(t, 1, version)
+
+	cache.EXPECT().Get(orderEntity.OrderUID).Return(nil, errors.New("cache error"))
+	repo.EXPECT().GetOrderByID(gomock.Any(), orderEntity.OrderUID).Return(orderEntity, nil)
+	cache.EXPECT().Set(orderEntity.OrderUID, orderEntity, ttl).Return(nil)
+
+	data, version, err = order.GetOrderJSON(context.Background(), orderEntity.OrderUID)
+	require.NoError(t, err)
+	require.Equal(t, encoded, data)
+	require.Equal(t, 2, version)
+
+	cache.EXPECT().Get("missing").Return(nil, errors.New("cache error"))
+	repo.EXPECT().GetOrderByID(gomock.Any(), "missing").Return(nil, usecase.ErrNotFound)
+
+	_, _, err = order.GetOrderJSON(context.Background(), "missing")
+	require.ErrorIs(t, err, usecase.ErrNotFound)
+}
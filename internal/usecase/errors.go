@@ -0,0 +1,37 @@
+package usecase
+
+import "errors"
+
+// Domain error kinds. Check them with errors.Is, the transport layer maps them to its own status codes.
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrInvalid       = errors.New("invalid")
+	ErrUnavailable   = errors.New("unavailable")
+	ErrConflict      = errors.New("conflict")
+)
+
+// ErrVersionMismatch is a conflict of an update made against a stale version of the order.
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// Error tags an error with a domain error kind while keeping its message and chain intact.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func NewError(kind, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
@@ -0,0 +1,20 @@
+package usecase_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("duplicate key")
+	err := fmt.Errorf("can not insert payment: %w", usecase.NewError(usecase.ErrAlreadyExists, cause))
+
+	require.Equal(t, "can not insert payment: duplicate key", err.Error())
+	require.True(t, errors.Is(err, usecase.ErrAlreadyExists))
+	require.True(t, errors.Is(err, cause))
+	require.False(t, errors.Is(err, usecase.ErrNotFound))
+}
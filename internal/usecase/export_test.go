@@ -0,0 +1,8 @@
+package usecase
+
+// SharedWait sets a hook called once a lookup waits for the shared repository call.
+func SharedWait(hook func(orderUID string)) Option {
+	return func(c *config) {
+		c.sharedWait = hook
+	}
+}
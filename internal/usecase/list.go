@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+const (
+	defaultListOrders = 20
+	maxListOrders     = 100
+)
+
+// OrderFilter selects the listed orders, zero fields match any order. CreatedFrom is inclusive and
+// CreatedTo is exclusive, amounts are inclusive and given in minor units of the payment currency.
+type OrderFilter struct {
+	CustomerID      string
+	DeliveryService string
+	Entry           string
+	Locale          string
+	CreatedFrom     time.Time
+	CreatedTo       time.Time
+	Provider        string
+	Currency        entity.Currency
+	MinAmount       *int64
+	MaxAmount       *int64
+	Brand           string
+}
+
+// OrderPage selects up to Limit orders, newest first, starting right after After (if set).
+type OrderPage struct {
+	After *OrderCursor
+	Limit int
+}
+
+// ListOrders returns a page of the orders matching the filter, newest first, and the cursor of the next page.
+// The cursor is nil on the last page. The page size defaults to 20 and is capped at 100.
+// Listed orders are read from the repository and are not put into the cache.
+func (ou OrderUsecase) ListOrders(
+	ctx context.Context,
+	filter OrderFilter,
+	page OrderPage,
+) ([]*entity.Order, *OrderCursor, error) {
+	limit := pageLimit(page.Limit)
+
+	// one more order tells whether there is a next page
+	page.Limit = limit + 1
+	orders, err := ou.repository.ListOrders(ctx, filter, page)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can not list orders: %w", err)
+	}
+	if len(orders) <= limit {
+		return orders, nil, nil
+	}
+
+	orders = orders[:limit]
+	return orders, cursorOf(orders[limit-1]), nil
+}
+
+// pageLimit bounds the number of orders returned at once by the listing and the search.
+func pageLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return defaultListOrders
+	case limit > maxListOrders:
+		return maxListOrders
+	default:
+		return limit
+	}
+}
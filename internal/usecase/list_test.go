@@ -0,0 +1,97 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func listedOrders(n int) []*entity.Order {
+	orders := make([]*entity.Order, 0, n)
+	for i := 0; i < n; i++ {
+		orders = append(orders, &entity.Order{
+			OrderUID:    fmt.Sprintf("uid-%d", i),
+			DateCreated: time.Date(2021, 11, 26, 0, 0, 0, 0, time.UTC).Add(-time.Duration(i) * time.Hour),
+		})
+	}
+	return orders
+}
+
+func TestOrderUsecase_ListOrders(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	repo := NewMockOrderRepository(mockCtl)
+	order := usecase.NewOrderUsecase(NewMockOrderCache(mockCtl), repo)
+	filter := usecase.OrderFilter{CustomerID: "test"}
+	after := &usecase.OrderCursor{OrderUID: "after"}
+
+	tests := []struct {
+		name   string
+		page   usecase.OrderPage
+		mock   func()
+		orders int
+		next   *usecase.OrderCursor
+		err    error
+	}{
+		{
+			name: "default limit, last page",
+			mock: func() {
+				repo.EXPECT().
+					ListOrders(context.Background(), filter, usecase.OrderPage{Limit: 21}).
+					Return(listedOrders(3), nil)
+			},
+			orders: 3,
+		},
+		{
+			name: "next page",
+			page: usecase.OrderPage{After: after, Limit: 2},
+			mock: func() {
+				repo.EXPECT().
+					ListOrders(context.Background(), filter, usecase.OrderPage{After: after, Limit: 3}).
+					Return(listedOrders(3), nil)
+			},
+			orders: 2,
+			next:   &usecase.OrderCursor{DateCreated: listedOrders(2)[1].DateCreated, OrderUID: "uid-1"},
+		},
+		{
+			name: "capped limit",
+			page: usecase.OrderPage{Limit: 1000},
+			mock: func() {
+				repo.EXPECT().
+					ListOrders(context.Background(), filter, usecase.OrderPage{Limit: 101}).
+					Return(listedOrders(100), nil)
+			},
+			orders: 100,
+		},
+		{
+			name: "error in repo",
+			mock: func() {
+				repo.EXPECT().ListOrders(context.Background(), filter, gomock.Any()).Return(nil, errors.New("repo error"))
+			},
+			err: errors.New("can not list orders: repo error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			orders, next, err := order.ListOrders(context.Background(), filter, tt.page)
+			if tt.err != nil {
+				require.Equal(t, tt.err.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, orders, tt.orders)
+			require.Equal(t, tt.next, next)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+// maxLookupOrders caps the orders returned by a single lookup, a customer may have many of them.
+const maxLookupOrders = 100
+
+// OrderLookup selects up to Limit orders holding Value in Field, newest first.
+type OrderLookup struct {
+	Field entity.LookupField
+	Value string
+	Limit int
+}
+
+// IndexedOrderCache is an OrderCache keeping secondary indexes of the orders.
+// FindOrders uses it when the cache implements it.
+type IndexedOrderCache interface {
+	Lookup(entity.LookupField, string) ([]*entity.Order, error)
+}
+
+// FindOrders looks orders up by a field other than the order uid. Lookups by a unique field are served
+// from the cache when it has the order. The cache may hold only part of the orders of a customer or
+// sharing a rid, so those are always read from the repository.
+func (ou OrderUsecase) FindOrders(ctx context.Context, field entity.LookupField, value string) ([]*entity.Order, error) {
+	if indexedCache, ok := ou.cache.(IndexedOrderCache); ok && field.Unique() {
+		if orders, err := indexedCache.Lookup(field, value); err == nil && len(orders) > 0 {
+			return orders, nil
+		}
+	}
+
+	orders, err := ou.repository.FindOrders(ctx, OrderLookup{Field: field, Value: value, Limit: maxLookupOrders})
+	if err != nil {
+		return nil, fmt.Errorf("can not find orders: %w", err)
+	}
+	for _, order := range orders {
+		// a failed cache fill only costs another repository round trip
+		_ = ou.cache.Set(order.OrderUID, order, ou.cacheTTL)
+	}
+	return orders, nil
+}
@@ -0,0 +1,131 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+type indexedOrderCache struct {
+	*MockOrderCache
+	orders []*entity.Order
+}
+
+func (c indexedOrderCache) Lookup(field entity.LookupField, value string) ([]*entity.Order, error) {
+	var orders []*entity.Order
+	for _, order := range c.orders {
+		for _, orderValue := range order.LookupValues(field) {
+			if orderValue == value {
+				orders = append(orders, order)
+				break
+			}
+		}
+	}
+	return orders, nil
+}
+
+func TestOrderUsecase_FindOrders(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	cached := validOrder(t)
+	stored := validOrder(t)
+	stored.OrderUID = "stored"
+	stored.TrackNumber = "STOREDTRACK"
+
+	repo := NewMockOrderRepository(mockCtl)
+	cache := indexedOrderCache{MockOrderCache: NewMockOrderCache(mockCtl), orders: []*entity.Order{cached}}
+	ttl := fakeTTL(t)
+	order := usecase.NewOrderUsecase(cache, repo, usecase.CacheTTL(ttl))
+
+	tests := []struct {
+		name   string
+		mock   func()
+		field  entity.LookupField
+		value  string
+		result []*entity.Order
+		err    error
+	}{
+		{
+			name:   "unique field in cache",
+			mock:   func() {},
+			field:  entity.LookupTrackNumber,
+			value:  cached.TrackNumber,
+			result: []*entity.Order{cached},
+		},
+		{
+			name: "unique field in repo",
+			mock: func() {
+				repo.EXPECT().
+					FindOrders(context.Background(), usecase.OrderLookup{
+						Field: entity.LookupTrackNumber,
+						Value: stored.TrackNumber,
+						Limit: 100,
+					}).
+					Return([]*entity.Order{stored}, nil)
+				cache.EXPECT().Set(stored.OrderUID, stored, ttl).Return(nil)
+			},
+			field:  entity.LookupTrackNumber,
+			value:  stored.TrackNumber,
+			result: []*entity.Order{stored},
+		},
+		{
+			name: "customer always in repo",
+			mock: func() {
+				repo.EXPECT().
+					FindOrders(context.Background(), gomock.Any()).
+					Return([]*entity.Order{stored, cached}, nil)
+				cache.EXPECT().Set(stored.OrderUID, stored, ttl).Return(nil)
+				cache.EXPECT().Set(cached.OrderUID, cached, ttl).Return(errors.New("cache error"))
+			},
+			field:  entity.LookupCustomerID,
+			value:  cached.CustomerID,
+			result: []*entity.Order{stored, cached},
+		},
+		{
+			name: "rid always in repo",
+			mock: func() {
+				repo.EXPECT().
+					FindOrders(context.Background(), usecase.OrderLookup{
+						Field: entity.LookupRid,
+						Value: cached.Items[0].Rid,
+						Limit: 100,
+					}).
+					Return([]*entity.Order{stored, cached}, nil)
+				cache.EXPECT().Set(stored.OrderUID, stored, ttl).Return(nil)
+				cache.EXPECT().Set(cached.OrderUID, cached, ttl).Return(nil)
+			},
+			field:  entity.LookupRid,
+			value:  cached.Items[0].Rid,
+			result: []*entity.Order{stored, cached},
+		},
+		{
+			name: "error in repo",
+			mock: func() {
+				repo.EXPECT().FindOrders(context.Background(), gomock.Any()).Return(nil, errors.New("repo error"))
+			},
+			field: entity.LookupRid,
+			value: "missing",
+			err:   errors.New("can not find orders: repo error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			result, err := order.FindOrders(context.Background(), tt.field, tt.value)
+			require.Equal(t, tt.result, result)
+			if tt.err != nil {
+				require.Equal(t, tt.err.Error(), err.Error())
+			} else {
+				require.Nil(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"sync"
+	"time"
+)
+
+const maxMissingOrders = 1 << 16
+
+// missingOrders remembers order uids which are known to be absent in the repository,
+// so that repeated lookups of them do not reach the database until ttl expires.
+type missingOrders struct {
+	mutex    sync.Mutex
+	ttl      time.Duration
+	expireAt map[string]time.Time
+}
+
+func newMissingOrders(ttl time.Duration) *missingOrders {
+	return &missingOrders{
+		ttl:      ttl,
+		expireAt: make(map[string]time.Time),
+	}
+}
+
+func (mo *missingOrders) contains(orderUID string) bool {
+	if mo.ttl <= 0 {
+		return false
+	}
+
+	mo.mutex.Lock()
+	defer mo.mutex.Unlock()
+
+	expireAt, ok := mo.expireAt[orderUID]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expireAt) {
+		delete(mo.expireAt, orderUID)
+		return false
+	}
+	return true
+}
+
+func (mo *missingOrders) add(orderUID string) {
+	if mo.ttl <= 0 {
+		return
+	}
+
+	mo.mutex.Lock()
+	defer mo.mutex.Unlock()
+
+	now := time.Now()
+	if len(mo.expireAt) >= maxMissingOrders {
+		for key, expireAt := range mo.expireAt {
+			if now.After(expireAt) {
+				delete(mo.expireAt, key)
+			}
+		}
+		if len(mo.expireAt) >= maxMissingOrders {
+			return
+		}
+	}
+	mo.expireAt[orderUID] = now.Add(mo.ttl)
+}
+
+func (mo *missingOrders) remove(orderUID string) {
+	mo.mutex.Lock()
+	delete(mo.expireAt, orderUID)
+	mo.mutex.Unlock()
+}
+
+// ForgetMissingOrder drops the order uid from the orders known to be missing,
+// so that an order created through another replica is found before the negative cache entry expires.
+func (ou OrderUsecase) ForgetMissingOrder(orderUID string) {
+	ou.missing.remove(orderUID)
+}
@@ -11,6 +11,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	entity "github.com/maypok86/wb-l0/internal/entity"
+	usecase "github.com/maypok86/wb-l0/internal/usecase"
 )
 
 // MockOrderCache is a mock of OrderCache interface.
@@ -36,6 +37,20 @@ func (m *MockOrderCache) EXPECT() *MockOrderCacheMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockOrderCache) Delete(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockOrderCacheMockRecorder) Delete(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockOrderCache)(nil).Delete), arg0)
+}
+
 // Get mocks base method.
 func (m *MockOrderCache) Get(arg0 string) (*entity.Order, error) {
 	m.ctrl.T.Helper()
@@ -88,6 +103,21 @@ func (m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CountOrders mocks base method.
+func (m *MockOrderRepository) CountOrders(arg0 context.Context, arg1 usecase.OrderBatch) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOrders", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOrders indicates an expected call of CountOrders.
+func (mr *MockOrderRepositoryMockRecorder) CountOrders(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOrders", reflect.TypeOf((*MockOrderRepository)(nil).CountOrders), arg0, arg1)
+}
+
 // CreateOrder mocks base method.
 func (m *MockOrderRepository) CreateOrder(arg0 context.Context, arg1 *entity.Order) (*entity.Order, error) {
 	m.ctrl.T.Helper()
@@ -103,19 +133,47 @@ func (mr *MockOrderRepositoryMockRecorder) CreateOrder(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderRepository)(nil).CreateOrder), arg0, arg1)
 }
 
-// GetAllOrders mocks base method.
-func (m *MockOrderRepository) GetAllOrders(arg0 context.Context) ([]*entity.Order, error) {
+// DeleteOrder mocks base method.
+func (m *MockOrderRepository) DeleteOrder(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllOrders", arg0)
+	ret := m.ctrl.Call(m, "DeleteOrder", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOrder indicates an expected call of DeleteOrder.
+func (mr *MockOrderRepositoryMockRecorder) DeleteOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrder", reflect.TypeOf((*MockOrderRepository)(nil).DeleteOrder), arg0, arg1)
+}
+
+// FindOrders mocks base method.
+func (m *MockOrderRepository) FindOrders(arg0 context.Context, arg1 usecase.OrderLookup) ([]*entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOrders", arg0, arg1)
 	ret0, _ := ret[0].([]*entity.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllOrders indicates an expected call of GetAllOrders.
-func (mr *MockOrderRepositoryMockRecorder) GetAllOrders(arg0 interface{}) *gomock.Call {
+// FindOrders indicates an expected call of FindOrders.
+func (mr *MockOrderRepositoryMockRecorder) FindOrders(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllOrders", reflect.TypeOf((*MockOrderRepository)(nil).GetAllOrders), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOrders", reflect.TypeOf((*MockOrderRepository)(nil).FindOrders), arg0, arg1)
+}
+
+// FlagDuplicate mocks base method.
+func (m *MockOrderRepository) FlagDuplicate(arg0 context.Context, arg1 *entity.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlagDuplicate", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlagDuplicate indicates an expected call of FlagDuplicate.
+func (mr *MockOrderRepositoryMockRecorder) FlagDuplicate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlagDuplicate", reflect.TypeOf((*MockOrderRepository)(nil).FlagDuplicate), arg0, arg1)
 }
 
 // GetOrderByID mocks base method.
@@ -132,3 +190,122 @@ func (mr *MockOrderRepositoryMockRecorder) GetOrderByID(arg0, arg1 interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockOrderRepository)(nil).GetOrderByID), arg0, arg1)
 }
+
+// GetOrdersBatch mocks base method.
+func (m *MockOrderRepository) GetOrdersBatch(arg0 context.Context, arg1 usecase.OrderBatch) ([]*entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersBatch", arg0, arg1)
+	ret0, _ := ret[0].([]*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersBatch indicates an expected call of GetOrdersBatch.
+func (mr *MockOrderRepositoryMockRecorder) GetOrdersBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersBatch", reflect.TypeOf((*MockOrderRepository)(nil).GetOrdersBatch), arg0, arg1)
+}
+
+// ListOrders mocks base method.
+func (m *MockOrderRepository) ListOrders(arg0 context.Context, arg1 usecase.OrderFilter, arg2 usecase.OrderPage) ([]*entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockOrderRepositoryMockRecorder) ListOrders(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockOrderRepository)(nil).ListOrders), arg0, arg1, arg2)
+}
+
+// LiveOrders mocks base method.
+func (m *MockOrderRepository) LiveOrders(arg0 context.Context, arg1 []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LiveOrders", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LiveOrders indicates an expected call of LiveOrders.
+func (mr *MockOrderRepositoryMockRecorder) LiveOrders(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LiveOrders", reflect.TypeOf((*MockOrderRepository)(nil).LiveOrders), arg0, arg1)
+}
+
+// OverwriteOrder mocks base method.
+func (m *MockOrderRepository) OverwriteOrder(arg0 context.Context, arg1 *entity.Order) (*entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OverwriteOrder", arg0, arg1)
+	ret0, _ := ret[0].(*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OverwriteOrder indicates an expected call of OverwriteOrder.
+func (mr *MockOrderRepositoryMockRecorder) OverwriteOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OverwriteOrder", reflect.TypeOf((*MockOrderRepository)(nil).OverwriteOrder), arg0, arg1)
+}
+
+// PurgeOrder mocks base method.
+func (m *MockOrderRepository) PurgeOrder(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeOrder", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeOrder indicates an expected call of PurgeOrder.
+func (mr *MockOrderRepositoryMockRecorder) PurgeOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeOrder", reflect.TypeOf((*MockOrderRepository)(nil).PurgeOrder), arg0, arg1)
+}
+
+// SearchOrders mocks base method.
+func (m *MockOrderRepository) SearchOrders(arg0 context.Context, arg1 usecase.OrderSearch) ([]*entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchOrders", arg0, arg1)
+	ret0, _ := ret[0].([]*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchOrders indicates an expected call of SearchOrders.
+func (mr *MockOrderRepositoryMockRecorder) SearchOrders(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchOrders", reflect.TypeOf((*MockOrderRepository)(nil).SearchOrders), arg0, arg1)
+}
+
+// UpdateOrder mocks base method.
+func (m *MockOrderRepository) UpdateOrder(arg0 context.Context, arg1 *entity.Order, arg2 int) (*entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrder", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOrder indicates an expected call of UpdateOrder.
+func (mr *MockOrderRepositoryMockRecorder) UpdateOrder(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrder", reflect.TypeOf((*MockOrderRepository)(nil).UpdateOrder), arg0, arg1, arg2)
+}
+
+// UpdateOrderStatus mocks base method.
+func (m *MockOrderRepository) UpdateOrderStatus(arg0 context.Context, arg1 string, arg2 entity.OrderStatus, arg3 entity.StatusChange) (*entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrderStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOrderStatus indicates an expected call of UpdateOrderStatus.
+func (mr *MockOrderRepositoryMockRecorder) UpdateOrderStatus(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateOrderStatus), arg0, arg1, arg2, arg3)
+}
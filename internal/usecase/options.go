@@ -0,0 +1,74 @@
+package usecase
+
+import "time"
+
+type config struct {
+	cacheTTL        time.Duration
+	negativeTTL     time.Duration
+	duplicatePolicy DuplicatePolicy
+	warmup          warmupConfig
+	sharedWait      func(orderUID string)
+}
+
+type warmupConfig struct {
+	window    time.Duration
+	limit     int
+	batchSize int
+}
+
+func getDefaultConfig() *config {
+	return &config{
+		cacheTTL:        time.Hour,
+		negativeTTL:     time.Minute,
+		duplicatePolicy: DuplicateReject,
+		warmup: warmupConfig{
+			batchSize: 1000,
+		},
+	}
+}
+
+type Option func(*config)
+
+// CacheTTL sets the ttl of orders put into the cache on a cache miss.
+func CacheTTL(cacheTTL time.Duration) Option {
+	return func(c *config) {
+		c.cacheTTL = cacheTTL
+	}
+}
+
+// NegativeCacheTTL sets how long an order uid missing in the repository is remembered. Zero disables it.
+func NegativeCacheTTL(negativeTTL time.Duration) Option {
+	return func(c *config) {
+		c.negativeTTL = negativeTTL
+	}
+}
+
+// OnDuplicate sets the policy for redelivered orders with content different from the stored ones.
+func OnDuplicate(policy DuplicatePolicy) Option {
+	return func(c *config) {
+		c.duplicatePolicy = policy
+	}
+}
+
+// WarmupWindow limits the cache warm-up to orders created within the window. Zero loads orders of any age.
+func WarmupWindow(window time.Duration) Option {
+	return func(c *config) {
+		c.warmup.window = window
+	}
+}
+
+// WarmupLimit limits the cache warm-up to the given number of the most recent orders. Zero disables the limit.
+func WarmupLimit(limit int) Option {
+	return func(c *config) {
+		c.warmup.limit = limit
+	}
+}
+
+// WarmupBatchSize sets how many orders are read from the repository at once during the cache warm-up.
+func WarmupBatchSize(batchSize int) Option {
+	return func(c *config) {
+		if batchSize > 0 {
+			c.warmup.batchSize = batchSize
+		}
+	}
+}
@@ -2,10 +2,14 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/pkg/logger"
+	"golang.org/x/sync/singleflight"
 )
 
 //go:generate mockgen -source=order.go -destination=mock_test.go -package=usecase_test
@@ -13,59 +17,165 @@ import (
 type OrderCache interface {
 	Set(string, *entity.Order, time.Duration) error
 	Get(string) (*entity.Order, error)
+	Delete(string) error
 }
 
 type OrderRepository interface {
 	CreateOrder(context.Context, *entity.Order) (*entity.Order, error)
 	GetOrderByID(context.Context, string) (*entity.Order, error)
-	GetAllOrders(context.Context) ([]*entity.Order, error)
+	CountOrders(context.Context, OrderBatch) (int, error)
+	LiveOrders(context.Context, []string) ([]string, error)
+	GetOrdersBatch(context.Context, OrderBatch) ([]*entity.Order, error)
+	FindOrders(context.Context, OrderLookup) ([]*entity.Order, error)
+	ListOrders(context.Context, OrderFilter, OrderPage) ([]*entity.Order, error)
+	SearchOrders(context.Context, OrderSearch) ([]*entity.Order, error)
+	UpdateOrderStatus(context.Context, string, entity.OrderStatus, entity.StatusChange) (*entity.Order, error)
+	OverwriteOrder(context.Context, *entity.Order) (*entity.Order, error)
+	FlagDuplicate(context.Context, *entity.Order) error
+	UpdateOrder(context.Context, *entity.Order, int) (*entity.Order, error)
+	DeleteOrder(context.Context, string) error
+	PurgeOrder(context.Context, string) error
 }
 
 type OrderUsecase struct {
-	cache      OrderCache
-	repository OrderRepository
+	cache           OrderCache
+	repository      OrderRepository
+	cacheTTL        time.Duration
+	duplicatePolicy DuplicatePolicy
+	warmup          warmupConfig
+	progress        *warmupProgress
+	missing         *missingOrders
+	group           *singleflight.Group
+	// sharedWait is called once a lookup waits for the shared repository call, it is set by tests
+	sharedWait func(orderUID string)
+	// loading serializes the warm-up and the cache resyncs
+	loading *sync.Mutex
 }
 
-func NewOrderUsecase(cache OrderCache, repository OrderRepository) OrderUsecase {
+func NewOrderUsecase(cache OrderCache, repository OrderRepository, opts ...Option) OrderUsecase {
+	cfg := getDefaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	return OrderUsecase{
-		cache:      cache,
-		repository: repository,
+		cache:           cache,
+		repository:      repository,
+		cacheTTL:        cfg.cacheTTL,
+		duplicatePolicy: cfg.duplicatePolicy,
+		warmup:          cfg.warmup,
+		progress:        newWarmupProgress(),
+		missing:         newMissingOrders(cfg.negativeTTL),
+		group:           &singleflight.Group{},
+		sharedWait:      cfg.sharedWait,
+		loading:         &sync.Mutex{},
 	}
 }
 
-func (ou OrderUsecase) CreateOrder(ctx context.Context, order *entity.Order, ttl time.Duration) (*entity.Order, error) {
-	order, err := ou.repository.CreateOrder(ctx, order)
+// CreateOrder stores a new order. Redelivery of an existing order is handled according to the duplicate policy,
+// the returned outcome tells which way it went.
+func (ou OrderUsecase) CreateOrder(
+	ctx context.Context,
+	order *entity.Order,
+	ttl time.Duration,
+) (*entity.Order, CreateOutcome, error) {
+	if err := order.Validate(); err != nil {
+		return nil, "", fmt.Errorf("can not create order: %w", NewError(ErrInvalid, err))
+	}
+
+	order.Version = 1
+	order.Status = entity.StatusCreated
+	order.StatusHistory = []entity.StatusChange{
+		{Status: entity.StatusCreated, Reason: "order created", ChangedAt: time.Now().UTC()},
+	}
+
+	created, err := ou.repository.CreateOrder(ctx, order)
+	if errors.Is(err, ErrAlreadyExists) {
+		return ou.createDuplicate(ctx, order, ttl, err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("can not create in repository: %w", err)
+		return nil, "", fmt.Errorf("can not create in repository: %w", err)
 	}
+
+	ou.missing.remove(created.OrderUID)
+	ou.cacheStored(created, ttl)
+	return created, OutcomeCreated, nil
+}
+
+// cacheStored caches an order already committed to the repository. A cache failure doesn't fail the call:
+// the message would be redelivered for an order that is stored, while a cache miss just falls through
+// to the repository. The possibly stale entry is dropped instead.
+func (ou OrderUsecase) cacheStored(order *entity.Order, ttl time.Duration) {
 	if err := ou.cache.Set(order.OrderUID, order, ttl); err != nil {
-		return nil, fmt.Errorf("can not create order in cache: %w", err)
+		logger.Errorf("can not set order %s to cache: %v", order.OrderUID, err)
+		_ = ou.cache.Delete(order.OrderUID)
 	}
-	return order, nil
 }
 
-func (ou OrderUsecase) GetOrderByID(ctx context.Context, orderUID string) (*entity.Order, error) {
-	order, err := ou.cache.Get(orderUID)
-	if err != nil {
-		order, err = ou.repository.GetOrderByID(ctx, orderUID)
-		if err != nil {
-			return nil, fmt.Errorf("can not get order by id: %w", err)
-		}
+// evictStored evicts an order changed in the repository. As with cacheStored, a cache failure doesn't fail
+// the committed change: the stale entry is served at most until it expires.
+func (ou OrderUsecase) evictStored(orderUID string) {
+	if err := ou.cache.Delete(orderUID); err != nil {
+		logger.Errorf("can not delete order %s from cache: %v", orderUID, err)
 	}
-	return order, nil
 }
 
-func (ou OrderUsecase) LoadDBToCache(ctx context.Context, ttl time.Duration) error {
-	orders, err := ou.repository.GetAllOrders(ctx)
+// UpdateOrder replaces the ingested data of a stored order, e.g. to correct the delivery address.
+// The update fails with ErrConflict if the stored order version differs from the given one.
+func (ou OrderUsecase) UpdateOrder(ctx context.Context, order *entity.Order, version int) (*entity.Order, error) {
+	if err := order.Validate(); err != nil {
+		return nil, fmt.Errorf("can not update order: %w", NewError(ErrInvalid, err))
+	}
+
+	updated, err := ou.repository.UpdateOrder(ctx, order, version)
 	if err != nil {
-		return fmt.Errorf("can not get all orders: %w", err)
+		return nil, fmt.Errorf("can not update in repository: %w", err)
 	}
 
-	for _, order := range orders {
-		if err := ou.cache.Set(order.OrderUID, order, ttl); err != nil {
-			return fmt.Errorf("can not set order to cache: %w", err)
+	ou.cacheStored(updated, ou.cacheTTL)
+	return updated, nil
+}
+
+// sharedLookupTimeout bounds a repository call shared by concurrent cache misses.
+const sharedLookupTimeout = 10 * time.Second
+
+// GetOrderByID reads the order from the cache and falls back to the repository on a miss.
+// Concurrent misses for the same order uid share a single repository call. The call doesn't depend
+// on the context of the caller that started it, so a caller going away fails only its own lookup.
+func (ou OrderUsecase) GetOrderByID(ctx context.Context, orderUID string) (*entity.Order, error) {
+	if order, err := ou.cache.Get(orderUID); err == nil {
+		return order, nil
+	}
+	if ou.missing.contains(orderUID) {
+		return nil, fmt.Errorf("can not get order by id: %w", ErrNotFound)
+	}
+
+	result := ou.group.DoChan(orderUID, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), sharedLookupTimeout)
+		defer cancel()
+
+		order, err := ou.repository.GetOrderByID(ctx, orderUID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				ou.missing.add(orderUID)
+			}
+			return nil, err
 		}
+		// The order is already read, so a failed cache fill only costs another repository call later.
+		_ = ou.cache.Set(orderUID, order, ou.cacheTTL)
+		return order, nil
+	})
+	if ou.sharedWait != nil {
+		ou.sharedWait(orderUID)
 	}
 
-	return nil
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("can not get order by id: %w", ctx.Err())
+	case res := <-result:
+		if res.Err != nil {
+			return nil, fmt.Errorf("can not get order by id: %w", res.Err)
+		}
+		return res.Val.(*entity.Order), nil
+	}
 }
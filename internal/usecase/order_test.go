@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"io/ioutil"
-	"math/rand"
+	"os"
 	"testing"
 	"time"
 
@@ -13,10 +13,19 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/maypok86/wb-l0/internal/entity"
 	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/maypok86/wb-l0/pkg/logger"
 	"github.com/stretchr/testify/require"
 )
 
-func mockOrderUsecase(t *testing.T) (usecase.OrderUsecase, *MockOrderCache, *MockOrderRepository) {
+func TestMain(m *testing.M) {
+	logger.Init("fatal")
+	os.Exit(m.Run())
+}
+
+func mockOrderUsecase(
+	t *testing.T,
+	opts ...usecase.Option,
+) (usecase.OrderUsecase, *MockOrderCache, *MockOrderRepository) {
 	t.Helper()
 
 	mockCtl := gomock.NewController(t)
@@ -24,7 +33,7 @@ func mockOrderUsecase(t *testing.T) (usecase.OrderUsecase, *MockOrderCache, *Moc
 
 	repo := NewMockOrderRepository(mockCtl)
 	cache := NewMockOrderCache(mockCtl)
-	order := usecase.NewOrderUsecase(cache, repo)
+	order := usecase.NewOrderUsecase(cache, repo, opts...)
 
 	return order, cache, repo
 }
@@ -52,14 +61,6 @@ func validOrder(t *testing.T) *entity.Order {
 	return order
 }
 
-func validOrders(t *testing.T, length int) []*entity.Order {
-	t.Helper()
-
-	orders := make([]*entity.Order, length)
-	require.NoError(t, faker.FakeData(&orders))
-	return orders
-}
-
 func fakeTTL(t *testing.T) time.Duration {
 	t.Helper()
 
@@ -70,22 +71,33 @@ func fakeTTL(t *testing.T) time.Duration {
 
 func TestOrderUsecase_CreateOrder(t *testing.T) {
 	order, cache, repo := mockOrderUsecase(t)
+	invalidOrder := validOrder(t)
 	validOrder := validOrder(t)
+	invalidOrder.Locale = ""
 	ttl := fakeTTL(t)
 
 	tests := []struct {
-		name   string
-		mock   func()
-		order  *entity.Order
-		result *entity.Order
-		err    error
+		name    string
+		mock    func()
+		order   *entity.Order
+		result  *entity.Order
+		outcome usecase.CreateOutcome
+		err     error
 	}{
+		{
+			name:    "invalid order",
+			mock:    func() {},
+			order:   invalidOrder,
+			result:  nil,
+			outcome: "",
+			err:     errors.New("can not create order: invalid order: locale: invalid locale code \"\""),
+		},
 		{
 			name: "error in repo",
 			mock: func() {
 				repo.EXPECT().CreateOrder(context.Background(), gomock.Any()).Return(nil, errors.New("some error"))
 			},
-			order:  nil,
+			order:  validOrder,
 			result: nil,
 			err:    errors.New("can not create in repository: some error"),
 		},
@@ -94,10 +106,12 @@ func TestOrderUsecase_CreateOrder(t *testing.T) {
 			mock: func() {
 				repo.EXPECT().CreateOrder(context.Background(), gomock.Any()).Return(validOrder, nil)
 				cache.EXPECT().Set(validOrder.OrderUID, gomock.Any(), gomock.Any()).Return(errors.New("some error"))
+				cache.EXPECT().Delete(validOrder.OrderUID).Return(nil)
 			},
-			order:  validOrder,
-			result: nil,
-			err:    errors.New("can not create order in cache: some error"),
+			order:   validOrder,
+			result:  validOrder,
+			outcome: usecase.OutcomeCreated,
+			err:     nil,
 		},
 		{
 			name: "success",
@@ -105,9 +119,10 @@ func TestOrderUsecase_CreateOrder(t *testing.T) {
 				repo.EXPECT().CreateOrder(context.Background(), gomock.Any()).Return(validOrder, nil)
 				cache.EXPECT().Set(validOrder.OrderUID, gomock.Any(), gomock.Any()).Return(nil)
 			},
-			order:  validOrder,
-			result: validOrder,
-			err:    nil,
+			order:   validOrder,
+			result:  validOrder,
+			outcome: usecase.OutcomeCreated,
+			err:     nil,
 		},
 	}
 
@@ -115,8 +130,9 @@ func TestOrderUsecase_CreateOrder(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			result, err := order.CreateOrder(context.Background(), tt.order, ttl)
+			result, outcome, err := order.CreateOrder(context.Background(), tt.order, ttl)
 			require.Equal(t, tt.result, result)
+			require.Equal(t, tt.outcome, outcome)
 			if tt.err != nil {
 				require.Equal(t, tt.err.Error(), err.Error())
 			} else {
@@ -126,43 +142,61 @@ func TestOrderUsecase_CreateOrder(t *testing.T) {
 	}
 }
 
-func TestOrderUsecase_GetOrderByID(t *testing.T) {
-	order, cache, repo := mockOrderUsecase(t)
+func TestOrderUsecase_UpdateOrder(t *testing.T) {
+	ttl := fakeTTL(t)
+	order, cache, repo := mockOrderUsecase(t, usecase.CacheTTL(ttl))
 
+	invalidOrder := validOrder(t)
+	invalidOrder.Payment.Provider = ""
 	orderEntity := validOrder(t)
+	updatedOrder := validOrder(t)
+	updatedOrder.Version = 3
+	const version = 2
 
 	tests := []struct {
 		name   string
 		mock   func()
+		order  *entity.Order
 		result *entity.Order
 		err    error
 	}{
 		{
-			name: "contains in cache",
-			mock: func() {
-				cache.EXPECT().Get(orderEntity.OrderUID).Return(orderEntity, nil)
-			},
-			result: orderEntity,
-			err:    nil,
+			name:   "invalid order",
+			mock:   func() {},
+			order:  invalidOrder,
+			result: nil,
+			err:    errors.New("can not update order: invalid order: payment.provider: is required"),
 		},
 		{
-			name: "error in repo",
+			name: "stale version",
 			mock: func() {
-				cache.EXPECT().Get(orderEntity.OrderUID).Return(nil, errors.New("cache error"))
 				repo.EXPECT().
-					GetOrderByID(context.Background(), orderEntity.OrderUID).
-					Return(nil, errors.New("repo error"))
+					UpdateOrder(context.Background(), orderEntity, version).
+					Return(nil, usecase.NewError(usecase.ErrConflict, usecase.ErrVersionMismatch))
 			},
+			order:  orderEntity,
 			result: nil,
-			err:    errors.New("can not get order by id: repo error"),
+			err:    errors.New("can not update in repository: version mismatch"),
 		},
 		{
-			name: "contains in repo",
+			name: "error in cache",
 			mock: func() {
-				cache.EXPECT().Get(orderEntity.OrderUID).Return(nil, errors.New("cache error"))
-				repo.EXPECT().GetOrderByID(context.Background(), orderEntity.OrderUID).Return(orderEntity, nil)
+				repo.EXPECT().UpdateOrder(context.Background(), orderEntity, version).Return(updatedOrder, nil)
+				cache.EXPECT().Set(updatedOrder.OrderUID, updatedOrder, ttl).Return(errors.New("some error"))
+				cache.EXPECT().Delete(updatedOrder.OrderUID).Return(nil)
 			},
-			result: orderEntity,
+			order:  orderEntity,
+			result: updatedOrder,
+			err:    nil,
+		},
+		{
+			name: "success",
+			mock: func() {
+				repo.EXPECT().UpdateOrder(context.Background(), orderEntity, version).Return(updatedOrder, nil)
+				cache.EXPECT().Set(updatedOrder.OrderUID, updatedOrder, ttl).Return(nil)
+			},
+			order:  orderEntity,
+			result: updatedOrder,
 			err:    nil,
 		},
 	}
@@ -171,7 +205,7 @@ func TestOrderUsecase_GetOrderByID(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			result, err := order.GetOrderByID(context.Background(), orderEntity.OrderUID)
+			result, err := order.UpdateOrder(context.Background(), tt.order, version)
 			require.Equal(t, tt.result, result)
 			if tt.err != nil {
 				require.Equal(t, tt.err.Error(), err.Error())
@@ -182,54 +216,81 @@ func TestOrderUsecase_GetOrderByID(t *testing.T) {
 	}
 }
 
-func TestOrderUsecase_LoadDBToCache(t *testing.T) {
-	order, cache, repo := mockOrderUsecase(t)
-
+func TestOrderUsecase_GetOrderByID(t *testing.T) {
 	ttl := fakeTTL(t)
-	const length = 10
-	orders := validOrders(t, length)
+	order, cache, repo := mockOrderUsecase(t, usecase.CacheTTL(ttl), usecase.NegativeCacheTTL(time.Minute))
 
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(orders), func(i, j int) {
-		orders[i], orders[j] = orders[j], orders[i]
-	})
-	index := rand.Intn(len(orders))
+	orderEntity := validOrder(t)
+	const missingUID = "missing"
 
 	tests := []struct {
-		name string
-		mock func()
-		err  error
+		name     string
+		mock     func()
+		orderUID string
+		result   *entity.Order
+		err      error
 	}{
+		{
+			name: "contains in cache",
+			mock: func() {
+				cache.EXPECT().Get(orderEntity.OrderUID).Return(orderEntity, nil)
+			},
+			orderUID: orderEntity.OrderUID,
+			result:   orderEntity,
+			err:      nil,
+		},
 		{
 			name: "error in repo",
 			mock: func() {
-				repo.EXPECT().GetAllOrders(context.Background()).Return(nil, errors.New("some error"))
+				cache.EXPECT().Get(orderEntity.OrderUID).Return(nil, errors.New("cache error"))
+				repo.EXPECT().
+					GetOrderByID(gomock.Any(), orderEntity.OrderUID).
+					Return(nil, errors.New("repo error"))
 			},
-			err: errors.New("can not get all orders: some error"),
+			orderUID: orderEntity.OrderUID,
+			result:   nil,
+			err:      errors.New("can not get order by id: repo error"),
 		},
 		{
-			name: "error in cache",
+			name: "contains in repo",
 			mock: func() {
-				repo.EXPECT().GetAllOrders(context.Background()).Return(orders, nil)
-				for i, order := range orders {
-					if i == index {
-						cache.EXPECT().Set(order.OrderUID, order, ttl).Return(errors.New("some error"))
-					} else {
-						cache.EXPECT().Set(order.OrderUID, order, ttl).Return(nil)
-					}
-				}
+				cache.EXPECT().Get(orderEntity.OrderUID).Return(nil, errors.New("cache error"))
+				repo.EXPECT().GetOrderByID(gomock.Any(), orderEntity.OrderUID).Return(orderEntity, nil)
+				cache.EXPECT().Set(orderEntity.OrderUID, orderEntity, ttl).Return(nil)
 			},
-			err: errors.New("can not set order to cache: some error"),
+			orderUID: orderEntity.OrderUID,
+			result:   orderEntity,
+			err:      nil,
 		},
 		{
-			name: "valid orders",
+			name: "error in cache fill",
 			mock: func() {
-				repo.EXPECT().GetAllOrders(context.Background()).Return(orders, nil)
-				for _, order := range orders {
-					cache.EXPECT().Set(order.OrderUID, order, ttl).Return(nil)
-				}
+				cache.EXPECT().Get(orderEntity.OrderUID).Return(nil, errors.New("cache error"))
+				repo.EXPECT().GetOrderByID(gomock.Any(), orderEntity.OrderUID).Return(orderEntity, nil)
+				cache.EXPECT().Set(orderEntity.OrderUID, orderEntity, ttl).Return(errors.New("cache error"))
+			},
+			orderUID: orderEntity.OrderUID,
+			result:   orderEntity,
+			err:      nil,
+		},
+		{
+			name: "not found in repo",
+			mock: func() {
+				cache.EXPECT().Get(missingUID).Return(nil, errors.New("cache error"))
+				repo.EXPECT().GetOrderByID(gomock.Any(), missingUID).Return(nil, usecase.ErrNotFound)
 			},
-			err: nil,
+			orderUID: missingUID,
+			result:   nil,
+			err:      errors.New("can not get order by id: not found"),
+		},
+		{
+			name: "known to be missing",
+			mock: func() {
+				cache.EXPECT().Get(missingUID).Return(nil, errors.New("cache error"))
+			},
+			orderUID: missingUID,
+			result:   nil,
+			err:      errors.New("can not get order by id: not found"),
 		},
 	}
 
@@ -237,7 +298,8 @@ func TestOrderUsecase_LoadDBToCache(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			err := order.LoadDBToCache(context.Background(), ttl)
+			result, err := order.GetOrderByID(context.Background(), tt.orderUID)
+			require.Equal(t, tt.result, result)
 			if tt.err != nil {
 				require.Equal(t, tt.err.Error(), err.Error())
 			} else {
@@ -246,3 +308,118 @@ func TestOrderUsecase_LoadDBToCache(t *testing.T) {
 		})
 	}
 }
+
+// lookupResult is the outcome of a GetOrderByID call made in another goroutine.
+type lookupResult struct {
+	order *entity.Order
+	err   error
+}
+
+func TestOrderUsecase_GetOrderByIDSingleflight(t *testing.T) {
+	const callers = 16
+
+	ttl := fakeTTL(t)
+	waiting := make(chan struct{}, callers)
+	order, cache, repo := mockOrderUsecase(t, usecase.CacheTTL(ttl), usecase.SharedWait(func(string) {
+		waiting <- struct{}{}
+	}))
+	orderEntity := validOrder(t)
+
+	release := make(chan struct{})
+	cache.EXPECT().Get(orderEntity.OrderUID).Times(callers).Return(nil, errors.New("cache error"))
+	repo.EXPECT().
+		GetOrderByID(gomock.Any(), orderEntity.OrderUID).
+		Times(1).
+		DoAndReturn(func(context.Context, string) (*entity.Order, error) {
+			<-release
+			return orderEntity, nil
+		})
+	cache.EXPECT().Set(orderEntity.OrderUID, orderEntity, ttl).Times(1).Return(nil)
+
+	results := make(chan lookupResult, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			result, err := order.GetOrderByID(context.Background(), orderEntity.OrderUID)
+			results <- lookupResult{order: result, err: err}
+		}()
+	}
+
+	// every caller waits for the in-flight repository call before it completes
+	for i := 0; i < callers; i++ {
+		<-waiting
+	}
+	close(release)
+	for i := 0; i < callers; i++ {
+		result := <-results
+		require.NoError(t, result.err)
+		require.Equal(t, orderEntity, result.order)
+	}
+}
+
+func TestOrderUsecase_GetOrderByIDCallerGone(t *testing.T) {
+	ttl := fakeTTL(t)
+	waiting := make(chan struct{}, 2)
+	order, cache, repo := mockOrderUsecase(t, usecase.CacheTTL(ttl), usecase.SharedWait(func(string) {
+		waiting <- struct{}{}
+	}))
+	orderEntity := validOrder(t)
+
+	release := make(chan struct{})
+	sharedErr := make(chan error, 1)
+	cache.EXPECT().Get(orderEntity.OrderUID).Times(2).Return(nil, errors.New("cache error"))
+	repo.EXPECT().
+		GetOrderByID(gomock.Any(), orderEntity.OrderUID).
+		DoAndReturn(func(ctx context.Context, _ string) (*entity.Order, error) {
+			<-release
+			sharedErr <- ctx.Err()
+			return orderEntity, nil
+		})
+	cache.EXPECT().Set(orderEntity.OrderUID, orderEntity, ttl).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gone := make(chan lookupResult, 1)
+	go func() {
+		result, err := order.GetOrderByID(ctx, orderEntity.OrderUID)
+		gone <- lookupResult{order: result, err: err}
+	}()
+	<-waiting
+
+	stayed := make(chan lookupResult, 1)
+	go func() {
+		result, err := order.GetOrderByID(context.Background(), orderEntity.OrderUID)
+		stayed <- lookupResult{order: result, err: err}
+	}()
+	<-waiting
+
+	cancel()
+	require.ErrorIs(t, (<-gone).err, context.Canceled)
+	close(release)
+
+	// the shared call outlives the caller that started it
+	require.NoError(t, <-sharedErr)
+	result := <-stayed
+	require.NoError(t, result.err)
+	require.Equal(t, orderEntity, result.order)
+}
+
+func TestOrderUsecase_ForgetMissingOrder(t *testing.T) {
+	order, cache, repo := mockOrderUsecase(t, usecase.NegativeCacheTTL(time.Minute))
+	orderEntity := validOrder(t)
+
+	cache.EXPECT().Get(orderEntity.OrderUID).Times(3).Return(nil, errors.New("cache error"))
+	gomock.InOrder(
+		repo.EXPECT().GetOrderByID(gomock.Any(), orderEntity.OrderUID).Return(nil, usecase.ErrNotFound),
+		repo.EXPECT().GetOrderByID(gomock.Any(), orderEntity.OrderUID).Return(orderEntity, nil),
+	)
+	cache.EXPECT().Set(orderEntity.OrderUID, orderEntity, gomock.Any()).Return(nil)
+
+	_, err := order.GetOrderByID(context.Background(), orderEntity.OrderUID)
+	require.ErrorIs(t, err, usecase.ErrNotFound)
+	_, err = order.GetOrderByID(context.Background(), orderEntity.OrderUID)
+	require.ErrorIs(t, err, usecase.ErrNotFound)
+
+	order.ForgetMissingOrder(orderEntity.OrderUID)
+	result, err := order.GetOrderByID(context.Background(), orderEntity.OrderUID)
+	require.NoError(t, err)
+	require.Equal(t, orderEntity, result)
+}
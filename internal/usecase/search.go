@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+// OrderSearch selects up to Limit orders with items matching Query, the best matches first.
+type OrderSearch struct {
+	Query string
+	Limit int
+}
+
+// SearchOrders looks for orders by the names and brands of their items. The number of orders
+// is bounded like in ListOrders. Found orders are read from the repository and are not put into the cache.
+func (ou OrderUsecase) SearchOrders(ctx context.Context, query string, limit int) ([]*entity.Order, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("can not search orders: %w", NewError(ErrInvalid, errors.New("empty search query")))
+	}
+
+	orders, err := ou.repository.SearchOrders(ctx, OrderSearch{Query: query, Limit: pageLimit(limit)})
+	if err != nil {
+		return nil, fmt.Errorf("can not search orders: %w", err)
+	}
+	return orders, nil
+}
@@ -0,0 +1,83 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderUsecase_SearchOrders(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	repo := NewMockOrderRepository(mockCtl)
+	order := usecase.NewOrderUsecase(NewMockOrderCache(mockCtl), repo)
+	found := listedOrders(2)
+
+	tests := []struct {
+		name   string
+		query  string
+		limit  int
+		mock   func()
+		result []*entity.Order
+		err    error
+	}{
+		{
+			name:  "trimmed query and default limit",
+			query: " mascaras ",
+			mock: func() {
+				repo.EXPECT().
+					SearchOrders(context.Background(), usecase.OrderSearch{Query: "mascaras", Limit: 20}).
+					Return(found, nil)
+			},
+			result: found,
+		},
+		{
+			name:  "capped limit",
+			query: "mascaras",
+			limit: 1000,
+			mock: func() {
+				repo.EXPECT().
+					SearchOrders(context.Background(), usecase.OrderSearch{Query: "mascaras", Limit: 100}).
+					Return(found, nil)
+			},
+			result: found,
+		},
+		{
+			name:  "empty query",
+			query: "  ",
+			mock:  func() {},
+			err:   usecase.ErrInvalid,
+		},
+		{
+			name:  "error in repo",
+			query: "mascaras",
+			mock: func() {
+				repo.EXPECT().SearchOrders(context.Background(), gomock.Any()).Return(nil, errors.New("repo error"))
+			},
+			err: errors.New("can not search orders: repo error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			result, err := order.SearchOrders(context.Background(), tt.query, tt.limit)
+			require.Equal(t, tt.result, result)
+			switch {
+			case errors.Is(tt.err, usecase.ErrInvalid):
+				require.ErrorIs(t, err, usecase.ErrInvalid)
+			case tt.err != nil:
+				require.Equal(t, tt.err.Error(), err.Error())
+			default:
+				require.NoError(t, err)
+			}
+		})
+	}
+}
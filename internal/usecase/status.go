@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+)
+
+var ErrIllegalTransition = errors.New("illegal order status transition")
+
+// transitions lists the statuses an order is allowed to move to from each status.
+var transitions = map[entity.OrderStatus][]entity.OrderStatus{
+	entity.StatusCreated:   {entity.StatusPaid, entity.StatusCancelled},
+	entity.StatusPaid:      {entity.StatusAssembled, entity.StatusCancelled},
+	entity.StatusAssembled: {entity.StatusShipped, entity.StatusCancelled},
+	entity.StatusShipped:   {entity.StatusDelivered, entity.StatusReturned},
+	entity.StatusDelivered: {entity.StatusReturned},
+	entity.StatusCancelled: {},
+	entity.StatusReturned:  {},
+}
+
+func canTransition(from, to entity.OrderStatus) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (ou OrderUsecase) TransitionOrder(
+	ctx context.Context,
+	orderUID string,
+	status entity.OrderStatus,
+	reason string,
+) (*entity.Order, error) {
+	order, err := ou.transition(ctx, orderUID, status, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	ou.cacheStored(order, ou.cacheTTL)
+	return order, nil
+}
+
+// CancelOrder moves the order to the cancelled status and evicts it from the cache,
+// so that the next read sees the cancellation.
+func (ou OrderUsecase) CancelOrder(ctx context.Context, orderUID, reason string) (*entity.Order, error) {
+	order, err := ou.transition(ctx, orderUID, entity.StatusCancelled, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	ou.evictStored(orderUID)
+	return order, nil
+}
+
+func (ou OrderUsecase) transition(
+	ctx context.Context,
+	orderUID string,
+	status entity.OrderStatus,
+	reason string,
+) (*entity.Order, error) {
+	order, err := ou.repository.GetOrderByID(ctx, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("can not get order by id: %w", err)
+	}
+
+	if !canTransition(order.Status, status) {
+		return nil, NewError(ErrConflict, fmt.Errorf("%w: from %s to %s", ErrIllegalTransition, order.Status, status))
+	}
+
+	order, err = ou.repository.UpdateOrderStatus(ctx, orderUID, order.Status, entity.StatusChange{
+		Status:    status,
+		Reason:    reason,
+		ChangedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can not update order status: %w", err)
+	}
+	return order, nil
+}
@@ -0,0 +1,167 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderUsecase_TransitionOrder(t *testing.T) {
+	ttl := fakeTTL(t)
+	order, cache, repo := mockOrderUsecase(t, usecase.CacheTTL(ttl))
+
+	createdOrder := validOrder(t)
+	createdOrder.Status = entity.StatusCreated
+	paidOrder := validOrder(t)
+	paidOrder.Status = entity.StatusPaid
+
+	tests := []struct {
+		name   string
+		mock   func()
+		status entity.OrderStatus
+		result *entity.Order
+		err    error
+	}{
+		{
+			name: "error in repo",
+			mock: func() {
+				repo.EXPECT().GetOrderByID(context.Background(), createdOrder.OrderUID).Return(nil, errors.New("some error"))
+			},
+			status: entity.StatusPaid,
+			result: nil,
+			err:    errors.New("can not get order by id: some error"),
+		},
+		{
+			name: "illegal transition",
+			mock: func() {
+				repo.EXPECT().GetOrderByID(context.Background(), createdOrder.OrderUID).Return(createdOrder, nil)
+			},
+			status: entity.StatusDelivered,
+			result: nil,
+			err:    errors.New("illegal order status transition: from created to delivered"),
+		},
+		{
+			name: "error in update",
+			mock: func() {
+				repo.EXPECT().GetOrderByID(context.Background(), createdOrder.OrderUID).Return(createdOrder, nil)
+				repo.EXPECT().
+					UpdateOrderStatus(context.Background(), createdOrder.OrderUID, entity.StatusCreated, gomock.Any()).
+					Return(nil, errors.New("some error"))
+			},
+			status: entity.StatusPaid,
+			result: nil,
+			err:    errors.New("can not update order status: some error"),
+		},
+		{
+			name: "error in cache",
+			mock: func() {
+				repo.EXPECT().GetOrderByID(context.Background(), createdOrder.OrderUID).Return(createdOrder, nil)
+				repo.EXPECT().
+					UpdateOrderStatus(context.Background(), createdOrder.OrderUID, entity.StatusCreated, gomock.Any()).
+					Return(paidOrder, nil)
+				cache.EXPECT().Set(paidOrder.OrderUID, paidOrder, ttl).Return(errors.New("some error"))
+				cache.EXPECT().Delete(paidOrder.OrderUID).Return(nil)
+			},
+			status: entity.StatusPaid,
+			result: paidOrder,
+			err:    nil,
+		},
+		{
+			name: "success",
+			mock: func() {
+				repo.EXPECT().GetOrderByID(context.Background(), createdOrder.OrderUID).Return(createdOrder, nil)
+				repo.EXPECT().
+					UpdateOrderStatus(context.Background(), createdOrder.OrderUID, entity.StatusCreated, gomock.Any()).
+					Return(paidOrder, nil)
+				cache.EXPECT().Set(paidOrder.OrderUID, paidOrder, ttl).Return(nil)
+			},
+			status: entity.StatusPaid,
+			result: paidOrder,
+			err:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			result, err := order.TransitionOrder(context.Background(), createdOrder.OrderUID, tt.status, "reason")
+			require.Equal(t, tt.result, result)
+			if tt.err != nil {
+				require.Equal(t, tt.err.Error(), err.Error())
+			} else {
+				require.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestOrderUsecase_CancelOrder(t *testing.T) {
+	order, cache, repo := mockOrderUsecase(t)
+
+	paidOrder := validOrder(t)
+	paidOrder.Status = entity.StatusPaid
+	deliveredOrder := validOrder(t)
+	deliveredOrder.Status = entity.StatusDelivered
+	cancelledOrder := validOrder(t)
+	cancelledOrder.Status = entity.StatusCancelled
+
+	tests := []struct {
+		name   string
+		mock   func()
+		result *entity.Order
+		err    error
+	}{
+		{
+			name: "illegal transition",
+			mock: func() {
+				repo.EXPECT().GetOrderByID(context.Background(), paidOrder.OrderUID).Return(deliveredOrder, nil)
+			},
+			result: nil,
+			err:    errors.New("illegal order status transition: from delivered to cancelled"),
+		},
+		{
+			name: "error in cache",
+			mock: func() {
+				repo.EXPECT().GetOrderByID(context.Background(), paidOrder.OrderUID).Return(paidOrder, nil)
+				repo.EXPECT().
+					UpdateOrderStatus(context.Background(), paidOrder.OrderUID, entity.StatusPaid, gomock.Any()).
+					Return(cancelledOrder, nil)
+				cache.EXPECT().Delete(paidOrder.OrderUID).Return(errors.New("some error"))
+			},
+			result: cancelledOrder,
+			err:    nil,
+		},
+		{
+			name: "success",
+			mock: func() {
+				repo.EXPECT().GetOrderByID(context.Background(), paidOrder.OrderUID).Return(paidOrder, nil)
+				repo.EXPECT().
+					UpdateOrderStatus(context.Background(), paidOrder.OrderUID, entity.StatusPaid, gomock.Any()).
+					Return(cancelledOrder, nil)
+				cache.EXPECT().Delete(paidOrder.OrderUID).Return(nil)
+			},
+			result: cancelledOrder,
+			err:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			result, err := order.CancelOrder(context.Background(), paidOrder.OrderUID, "reason")
+			require.Equal(t, tt.result, result)
+			if tt.err != nil {
+				require.Equal(t, tt.err.Error(), err.Error())
+			} else {
+				require.Nil(t, err)
+			}
+		})
+	}
+}
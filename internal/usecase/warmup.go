@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/pkg/logger"
+)
+
+// OrderCursor is the position of an order in the newest first order of the orders table.
+type OrderCursor struct {
+	DateCreated time.Time
+	OrderUID    string
+}
+
+func cursorOf(order *entity.Order) *OrderCursor {
+	return &OrderCursor{DateCreated: order.DateCreated, OrderUID: order.OrderUID}
+}
+
+// OrderBatch selects up to Limit orders created not earlier than Since and changed not earlier
+// than ChangedSince (if set), newest first, starting right after After (if set).
+type OrderBatch struct {
+	After        *OrderCursor
+	Since        time.Time
+	ChangedSince time.Time
+	Limit        int
+}
+
+type WarmupState string
+
+const (
+	WarmupPending WarmupState = "pending"
+	WarmupRunning WarmupState = "warming"
+	WarmupDone    WarmupState = "ready"
+	WarmupFailed  WarmupState = "failed"
+)
+
+// WarmupStatus is a snapshot of the cache warm-up progress.
+type WarmupStatus struct {
+	State  WarmupState
+	Loaded int
+	Total  int
+}
+
+// Percent returns the share of the loaded orders. It is 100 once the warm-up is done.
+func (ws WarmupStatus) Percent() int {
+	const hundred = 100
+	switch {
+	case ws.State == WarmupDone, ws.Total > 0 && ws.Loaded >= ws.Total:
+		return hundred
+	case ws.Total == 0:
+		return 0
+	default:
+		return ws.Loaded * hundred / ws.Total
+	}
+}
+
+type warmupProgress struct {
+	mutex  sync.RWMutex
+	status WarmupStatus
+}
+
+func newWarmupProgress() *warmupProgress {
+	return &warmupProgress{status: WarmupStatus{State: WarmupPending}}
+}
+
+// start resets the progress for a load of total orders. A nil progress tracks nothing,
+// loads other than the warm-up pass it.
+func (wp *warmupProgress) start(total int) {
+	if wp == nil {
+		return
+	}
+	wp.mutex.Lock()
+	wp.status = WarmupStatus{State: WarmupRunning, Total: total}
+	wp.mutex.Unlock()
+}
+
+func (wp *warmupProgress) advance(loaded int) {
+	if wp == nil {
+		return
+	}
+	wp.mutex.Lock()
+	wp.status.Loaded += loaded
+	wp.mutex.Unlock()
+}
+
+func (wp *warmupProgress) finish(err error) {
+	wp.mutex.Lock()
+	if err != nil {
+		wp.status.State = WarmupFailed
+	} else {
+		wp.status.State = WarmupDone
+	}
+	wp.mutex.Unlock()
+}
+
+func (wp *warmupProgress) get() WarmupStatus {
+	wp.mutex.RLock()
+	defer wp.mutex.RUnlock()
+
+	return wp.status
+}
+
+// WarmupStatus reports how far LoadDBToCache got.
+func (ou OrderUsecase) WarmupStatus() WarmupStatus {
+	return ou.progress.get()
+}
+
+// BatchOrderCache is an OrderCache able to store many orders at once, e.g. in a single network round trip.
+// The warm-up uses it when the cache implements it. A cache shared by the replicas may keep the entries
+// it already holds, they were written after the batch was read.
+type BatchOrderCache interface {
+	SetMany([]*entity.Order, time.Duration) error
+}
+
+// WarmedOrderCache is an OrderCache shared by the replicas that remembers a finished warm-up,
+// so that replicas started later skip it. LoadDBToCache uses it when the cache implements it.
+type WarmedOrderCache interface {
+	Warmed() (bool, error)
+	MarkWarmed(time.Duration) error
+}
+
+func (ou OrderUsecase) cacheOrders(orders []*entity.Order, ttl time.Duration) error {
+	if batchCache, ok := ou.cache.(BatchOrderCache); ok {
+		if err := batchCache.SetMany(orders, ttl); err != nil {
+			return fmt.Errorf("can not set orders to cache: %w", err)
+		}
+		return nil
+	}
+
+	for _, order := range orders {
+		if err := ou.cache.Set(order.OrderUID, order, ttl); err != nil {
+			return fmt.Errorf("can not set order to cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadDBToCache warms up the cache with the most recent orders. Orders are streamed from the repository
+// in batches, so only one batch is held in memory at a time.
+//
+// Lookups don't depend on the warm-up: until it is done a cache miss falls through to the repository.
+func (ou OrderUsecase) LoadDBToCache(ctx context.Context, ttl time.Duration) error {
+	ou.loading.Lock()
+	defer ou.loading.Unlock()
+
+	warmedCache, shared := ou.cache.(WarmedOrderCache)
+	if shared {
+		if warmed, err := warmedCache.Warmed(); err != nil {
+			logger.Warnf("can not check whether the cache is warmed up, warming it up: %v", err)
+		} else if warmed {
+			logger.Info("Cache is already warmed up by another replica, the warm-up is skipped")
+			ou.progress.finish(nil)
+			return nil
+		}
+	}
+
+	err := ou.loadDBToCache(ctx, ttl, time.Time{}, ou.progress)
+	ou.progress.finish(err)
+	if err == nil && shared {
+		if err := warmedCache.MarkWarmed(ttl); err != nil {
+			logger.Errorf("can not mark the cache as warmed up: %v", err)
+		}
+	}
+	return err
+}
+
+// KeyedOrderCache is an OrderCache able to list the order uids it holds. ReconcileCache uses it when
+// the cache implements it.
+type KeyedOrderCache interface {
+	Keys() []string
+}
+
+// ReconcileCache completes a cache restored from a snapshot taken at since: it evicts the orders deleted
+// or purged since then and loads the orders changed after that, within the warm-up bounds.
+func (ou OrderUsecase) ReconcileCache(ctx context.Context, ttl time.Duration, since time.Time) error {
+	ou.loading.Lock()
+	defer ou.loading.Unlock()
+
+	err := ou.evictGoneOrders(ctx)
+	if err == nil {
+		err = ou.loadDBToCache(ctx, ttl, since, ou.progress)
+	}
+	ou.progress.finish(err)
+	return err
+}
+
+// evictGoneOrders drops the cached orders which are deleted or no longer stored.
+func (ou OrderUsecase) evictGoneOrders(ctx context.Context) error {
+	keyedCache, ok := ou.cache.(KeyedOrderCache)
+	if !ok {
+		return nil
+	}
+
+	keys := keyedCache.Keys()
+	evicted := 0
+	for start := 0; start < len(keys); start += ou.warmup.batchSize {
+		end := start + ou.warmup.batchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		live, err := ou.repository.LiveOrders(ctx, keys[start:end])
+		if err != nil {
+			return fmt.Errorf("can not check cached orders: %w", err)
+		}
+		isLive := make(map[string]struct{}, len(live))
+		for _, orderUID := range live {
+			isLive[orderUID] = struct{}{}
+		}
+
+		for _, key := range keys[start:end] {
+			if _, ok := isLive[key]; ok {
+				continue
+			}
+			if err := ou.cache.Delete(key); err != nil {
+				return fmt.Errorf("can not delete order from cache: %w", err)
+			}
+			evicted++
+		}
+	}
+
+	logger.Infof("Cache reconcile: %d of %d cached orders are gone and evicted", evicted, len(keys))
+	return nil
+}
+
+// ClearableOrderCache is an OrderCache able to drop all its entries. ResyncCache uses it when the cache implements it.
+type ClearableOrderCache interface {
+	Clear()
+}
+
+// ResyncCache drops the cache and loads it again, e.g. after notifications about changed orders were missed.
+// Unlike the warm-up it leaves the warm-up status as is. A resync requested during the warm-up runs after it.
+func (ou OrderUsecase) ResyncCache(ctx context.Context, ttl time.Duration) error {
+	ou.loading.Lock()
+	defer ou.loading.Unlock()
+
+	if clearableCache, ok := ou.cache.(ClearableOrderCache); ok {
+		clearableCache.Clear()
+	}
+	return ou.loadDBToCache(ctx, ttl, time.Time{}, nil)
+}
+
+func (ou OrderUsecase) loadDBToCache(
+	ctx context.Context,
+	ttl time.Duration,
+	changedSince time.Time,
+	progress *warmupProgress,
+) error {
+	batch := OrderBatch{ChangedSince: changedSince}
+	if ou.warmup.window > 0 {
+		batch.Since = time.Now().Add(-ou.warmup.window)
+	}
+
+	total, err := ou.repository.CountOrders(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("can not count orders: %w", err)
+	}
+	if ou.warmup.limit > 0 && ou.warmup.limit < total {
+		total = ou.warmup.limit
+	}
+	progress.start(total)
+
+	start := time.Now()
+	loaded := 0
+	for {
+		batch.Limit = ou.warmup.batchSize
+		if ou.warmup.limit > 0 && ou.warmup.limit-loaded < batch.Limit {
+			batch.Limit = ou.warmup.limit - loaded
+		}
+		if batch.Limit <= 0 {
+			break
+		}
+
+		orders, err := ou.repository.GetOrdersBatch(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("can not get orders batch: %w", err)
+		}
+
+		if err := ou.cacheOrders(orders, ttl); err != nil {
+			return err
+		}
+		loaded += len(orders)
+		progress.advance(len(orders))
+
+		if len(orders) < batch.Limit {
+			break
+		}
+		batch.After = cursorOf(orders[len(orders)-1])
+		logger.Infof("Cache load: %d of %d orders loaded", loaded, total)
+	}
+
+	logger.Infof("Cache load finished: %d orders loaded in %s", loaded, time.Since(start))
+	return nil
+}
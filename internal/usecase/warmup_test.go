@@ -0,0 +1,412 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/maypok86/wb-l0/internal/entity"
+	"github.com/maypok86/wb-l0/internal/usecase"
+	"github.com/stretchr/testify/require"
+)
+
+// newestFirstOrders returns orders in the order the repository streams them.
+func newestFirstOrders(t *testing.T, length int) []*entity.Order {
+	t.Helper()
+
+	orders := make([]*entity.Order, length)
+	for i := range orders {
+		orders[i] = validOrder(t)
+		orders[i].OrderUID = fmt.Sprintf("order-%d", i)
+		orders[i].DateCreated = orders[i].DateCreated.Add(-time.Duration(i) * time.Hour)
+	}
+	return orders
+}
+
+func TestOrderUsecase_LoadDBToCache(t *testing.T) {
+	const batchSize = 4
+	order, cache, repo := mockOrderUsecase(t, usecase.WarmupBatchSize(batchSize))
+
+	ttl := fakeTTL(t)
+	const length = 10
+	orders := newestFirstOrders(t, length)
+
+	rand.Seed(time.Now().UnixNano())
+	index := rand.Intn(batchSize)
+
+	firstBatch := usecase.OrderBatch{Limit: batchSize}
+	secondBatch := usecase.OrderBatch{
+		After: &usecase.OrderCursor{DateCreated: orders[3].DateCreated, OrderUID: orders[3].OrderUID},
+		Limit: batchSize,
+	}
+	thirdBatch := usecase.OrderBatch{
+		After: &usecase.OrderCursor{DateCreated: orders[7].DateCreated, OrderUID: orders[7].OrderUID},
+		Limit: batchSize,
+	}
+
+	tests := []struct {
+		name string
+		mock func()
+		err  error
+	}{
+		{
+			name: "error in count",
+			mock: func() {
+				repo.EXPECT().CountOrders(context.Background(), usecase.OrderBatch{}).Return(0, errors.New("some error"))
+			},
+			err: errors.New("can not count orders: some error"),
+		},
+		{
+			name: "error in repo",
+			mock: func() {
+				repo.EXPECT().CountOrders(context.Background(), usecase.OrderBatch{}).Return(length, nil)
+				repo.EXPECT().GetOrdersBatch(context.Background(), firstBatch).Return(nil, errors.New("some error"))
+			},
+			err: errors.New("can not get orders batch: some error"),
+		},
+		{
+			name: "error in cache",
+			mock: func() {
+				repo.EXPECT().CountOrders(context.Background(), usecase.OrderBatch{}).Return(length, nil)
+				repo.EXPECT().GetOrdersBatch(context.Background(), firstBatch).Return(orders[:4], nil)
+				for i, order := range orders[:index+1] {
+					if i == index {
+						cache.EXPECT().Set(order.OrderUID, order, ttl).Return(errors.New("some error"))
+					} else {
+						cache.EXPECT().Set(order.OrderUID, order, ttl).Return(nil)
+					}
+				}
+			},
+			err: errors.New("can not set order to cache: some error"),
+		},
+		{
+			name: "empty repo",
+			mock: func() {
+				repo.EXPECT().CountOrders(context.Background(), usecase.OrderBatch{}).Return(0, nil)
+				repo.EXPECT().GetOrdersBatch(context.Background(), firstBatch).Return(nil, nil)
+			},
+			err: nil,
+		},
+		{
+			name: "valid orders",
+			mock: func() {
+				repo.EXPECT().CountOrders(context.Background(), usecase.OrderBatch{}).Return(length, nil)
+				gomock.InOrder(
+					repo.EXPECT().GetOrdersBatch(context.Background(), firstBatch).Return(orders[:4], nil),
+					repo.EXPECT().GetOrdersBatch(context.Background(), secondBatch).Return(orders[4:8], nil),
+					repo.EXPECT().GetOrdersBatch(context.Background(), thirdBatch).Return(orders[8:], nil),
+				)
+				for _, order := range orders {
+					cache.EXPECT().Set(order.OrderUID, order, ttl).Return(nil)
+				}
+			},
+			err: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := order.LoadDBToCache(context.Background(), ttl)
+			if tt.err != nil {
+				require.Equal(t, tt.err.Error(), err.Error())
+				require.Equal(t, usecase.WarmupFailed, order.WarmupStatus().State)
+			} else {
+				require.Nil(t, err)
+				require.Equal(t, usecase.WarmupDone, order.WarmupStatus().State)
+				require.Equal(t, 100, order.WarmupStatus().Percent())
+			}
+		})
+	}
+}
+
+func TestOrderUsecase_LoadDBToCacheLimit(t *testing.T) {
+	const (
+		batchSize = 4
+		limit     = 5
+		window    = 24 * time.Hour
+	)
+	order, cache, repo := mockOrderUsecase(
+		t,
+		usecase.WarmupBatchSize(batchSize),
+		usecase.WarmupLimit(limit),
+		usecase.WarmupWindow(window),
+	)
+
+	ttl := fakeTTL(t)
+	orders := newestFirstOrders(t, limit)
+
+	var batches []usecase.OrderBatch
+	recordBatch := func(result []*entity.Order) func(context.Context, usecase.OrderBatch) ([]*entity.Order, error) {
+		return func(_ context.Context, batch usecase.OrderBatch) ([]*entity.Order, error) {
+			batches = append(batches, batch)
+			return result, nil
+		}
+	}
+	repo.EXPECT().CountOrders(context.Background(), gomock.Any()).Return(100, nil)
+	gomock.InOrder(
+		repo.EXPECT().GetOrdersBatch(context.Background(), gomock.Any()).DoAndReturn(recordBatch(orders[:4])),
+		repo.EXPECT().GetOrdersBatch(context.Background(), gomock.Any()).DoAndReturn(recordBatch(orders[4:])),
+	)
+	for _, order := range orders {
+		cache.EXPECT().Set(order.OrderUID, order, ttl).Return(nil)
+	}
+
+	require.Equal(t, usecase.WarmupPending, order.WarmupStatus().State)
+	before := time.Now()
+	require.NoError(t, order.LoadDBToCache(context.Background(), ttl))
+	require.Equal(t, usecase.WarmupStatus{State: usecase.WarmupDone, Loaded: limit, Total: limit}, order.WarmupStatus())
+
+	require.Len(t, batches, 2)
+	require.Equal(t, batchSize, batches[0].Limit)
+	require.Equal(t, limit-batchSize, batches[1].Limit)
+	require.Equal(t, batches[0].Since, batches[1].Since)
+	require.WithinDuration(t, before.Add(-window), batches[0].Since, time.Second)
+	require.Equal(t, orders[3].OrderUID, batches[1].After.OrderUID)
+}
+
+func TestWarmupStatus_Percent(t *testing.T) {
+	tests := []struct {
+		name   string
+		status usecase.WarmupStatus
+		want   int
+	}{
+		{name: "pending", status: usecase.WarmupStatus{State: usecase.WarmupPending}, want: 0},
+		{name: "warming", status: usecase.WarmupStatus{State: usecase.WarmupRunning, Loaded: 25, Total: 200}, want: 12},
+		{name: "nothing to load", status: usecase.WarmupStatus{State: usecase.WarmupRunning}, want: 0},
+		{name: "done", status: usecase.WarmupStatus{State: usecase.WarmupDone}, want: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.status.Percent())
+		})
+	}
+}
+
+func TestOrderUsecase_ReconcileCache(t *testing.T) {
+	order, cache, repo := mockOrderUsecase(t, usecase.WarmupBatchSize(4), usecase.WarmupWindow(time.Hour))
+
+	ttl := fakeTTL(t)
+	orders := newestFirstOrders(t, 2)
+
+	tests := []struct {
+		name  string
+		since time.Time
+	}{
+		{
+			name:  "snapshot within the window",
+			since: time.Now().Add(-time.Minute),
+		},
+		{
+			name:  "snapshot older than the window",
+			since: time.Now().Add(-2 * time.Hour),
+		},
+	}
+
+	// the window bounds date_created and the snapshot bounds changed_at independently
+	checkBatch := func(t *testing.T, since time.Time, batch usecase.OrderBatch) {
+		require.Equal(t, since, batch.ChangedSince)
+		require.WithinDuration(t, time.Now().Add(-time.Hour), batch.Since, time.Second)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.EXPECT().CountOrders(context.Background(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, batch usecase.OrderBatch) (int, error) {
+					checkBatch(t, tt.since, batch)
+					return len(orders), nil
+				},
+			)
+			repo.EXPECT().GetOrdersBatch(context.Background(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, batch usecase.OrderBatch) ([]*entity.Order, error) {
+					checkBatch(t, tt.since, batch)
+					return orders, nil
+				},
+			)
+			for _, order := range orders {
+				cache.EXPECT().Set(order.OrderUID, order, ttl).Return(nil)
+			}
+
+			require.NoError(t, order.ReconcileCache(context.Background(), ttl, tt.since))
+			require.Equal(t, usecase.WarmupDone, order.WarmupStatus().State)
+		})
+	}
+}
+
+type keyedOrderCache struct {
+	*MockOrderCache
+	keys []string
+}
+
+func (c *keyedOrderCache) Keys() []string {
+	return c.keys
+}
+
+func TestOrderUsecase_ReconcileCacheEvictsGone(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	repo := NewMockOrderRepository(mockCtl)
+	cache := &keyedOrderCache{
+		MockOrderCache: NewMockOrderCache(mockCtl),
+		keys:           []string{"live-1", "deleted", "live-2", "purged", "live-3"},
+	}
+	order := usecase.NewOrderUsecase(cache, repo, usecase.WarmupBatchSize(2))
+
+	ttl := fakeTTL(t)
+	since := time.Now().Add(-time.Minute)
+
+	gomock.InOrder(
+		repo.EXPECT().LiveOrders(context.Background(), []string{"live-1", "deleted"}).Return([]string{"live-1"}, nil),
+		repo.EXPECT().LiveOrders(context.Background(), []string{"live-2", "purged"}).Return([]string{"live-2"}, nil),
+		repo.EXPECT().LiveOrders(context.Background(), []string{"live-3"}).Return([]string{"live-3"}, nil),
+	)
+	cache.EXPECT().Delete("deleted").Return(nil)
+	cache.EXPECT().Delete("purged").Return(nil)
+	repo.EXPECT().CountOrders(context.Background(), usecase.OrderBatch{ChangedSince: since}).Return(0, nil)
+	repo.EXPECT().GetOrdersBatch(context.Background(), gomock.Any()).Return(nil, nil)
+
+	require.NoError(t, order.ReconcileCache(context.Background(), ttl, since))
+	require.Equal(t, usecase.WarmupDone, order.WarmupStatus().State)
+
+	// a failed check leaves the restored entries as they are
+	repo.EXPECT().LiveOrders(context.Background(), gomock.Any()).Return(nil, errors.New("some error"))
+	require.Error(t, order.ReconcileCache(context.Background(), ttl, since))
+	require.Equal(t, usecase.WarmupFailed, order.WarmupStatus().State)
+}
+
+type batchOrderCache struct {
+	*MockOrderCache
+	batches [][]*entity.Order
+}
+
+func (c *batchOrderCache) SetMany(orders []*entity.Order, _ time.Duration) error {
+	c.batches = append(c.batches, orders)
+	return nil
+}
+
+func TestOrderUsecase_LoadDBToCacheBatchCache(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	repo := NewMockOrderRepository(mockCtl)
+	cache := &batchOrderCache{MockOrderCache: NewMockOrderCache(mockCtl)}
+	order := usecase.NewOrderUsecase(cache, repo, usecase.WarmupBatchSize(4))
+
+	orders := newestFirstOrders(t, 6)
+	repo.EXPECT().CountOrders(context.Background(), usecase.OrderBatch{}).Return(len(orders), nil)
+	gomock.InOrder(
+		repo.EXPECT().GetOrdersBatch(context.Background(), gomock.Any()).Return(orders[:4], nil),
+		repo.EXPECT().GetOrdersBatch(context.Background(), gomock.Any()).Return(orders[4:], nil),
+	)
+
+	require.NoError(t, order.LoadDBToCache(context.Background(), fakeTTL(t)))
+	require.Equal(t, [][]*entity.Order{orders[:4], orders[4:]}, cache.batches)
+}
+
+type warmedOrderCache struct {
+	*MockOrderCache
+	warmed   bool
+	markedAt time.Duration
+}
+
+func (c *warmedOrderCache) Warmed() (bool, error) {
+	return c.warmed, nil
+}
+
+func (c *warmedOrderCache) MarkWarmed(ttl time.Duration) error {
+	c.warmed, c.markedAt = true, ttl
+	return nil
+}
+
+func TestOrderUsecase_LoadDBToCacheWarmedCache(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	repo := NewMockOrderRepository(mockCtl)
+	cache := &warmedOrderCache{MockOrderCache: NewMockOrderCache(mockCtl)}
+	ttl := fakeTTL(t)
+	orders := newestFirstOrders(t, 2)
+
+	// the first replica warms the shared cache up and marks it
+	first := usecase.NewOrderUsecase(cache, repo)
+	repo.EXPECT().CountOrders(context.Background(), usecase.OrderBatch{}).Return(len(orders), nil)
+	repo.EXPECT().GetOrdersBatch(context.Background(), gomock.Any()).Return(orders, nil)
+	for _, order := range orders {
+		cache.EXPECT().Set(order.OrderUID, order, ttl).Return(nil)
+	}
+	require.NoError(t, first.LoadDBToCache(context.Background(), ttl))
+	require.Equal(t, ttl, cache.markedAt)
+
+	// a replica started later skips the warm-up, the repository is not called again
+	second := usecase.NewOrderUsecase(cache, repo)
+	require.NoError(t, second.LoadDBToCache(context.Background(), ttl))
+	require.Equal(t, usecase.WarmupDone, second.WarmupStatus().State)
+}
+
+type clearableOrderCache struct {
+	*MockOrderCache
+	cleared int
+}
+
+func (c *clearableOrderCache) Clear() {
+	c.cleared++
+}
+
+func TestOrderUsecase_ResyncCache(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	defer mockCtl.Finish()
+
+	repo := NewMockOrderRepository(mockCtl)
+	cache := &clearableOrderCache{MockOrderCache: NewMockOrderCache(mockCtl)}
+	order := usecase.NewOrderUsecase(cache, repo)
+	ttl := fakeTTL(t)
+	orders := newestFirstOrders(t, 2)
+
+	// the warm-up is blocked in the repository while the resync is requested
+	warmupStarted, releaseWarmup := make(chan struct{}), make(chan struct{})
+	gomock.InOrder(
+		repo.EXPECT().CountOrders(context.Background(), usecase.OrderBatch{}).DoAndReturn(
+			func(context.Context, usecase.OrderBatch) (int, error) {
+				close(warmupStarted)
+				<-releaseWarmup
+				return 0, nil
+			},
+		),
+		repo.EXPECT().GetOrdersBatch(context.Background(), gomock.Any()).Return(nil, nil),
+		repo.EXPECT().CountOrders(context.Background(), usecase.OrderBatch{}).Return(len(orders), nil),
+		repo.EXPECT().GetOrdersBatch(context.Background(), gomock.Any()).Return(orders, nil),
+	)
+	for _, order := range orders {
+		cache.EXPECT().Set(order.OrderUID, order, ttl).Return(nil)
+	}
+
+	warmupDone := make(chan error)
+	go func() {
+		warmupDone <- order.LoadDBToCache(context.Background(), ttl)
+	}()
+	<-warmupStarted
+
+	resyncDone := make(chan error)
+	go func() {
+		resyncDone <- order.ResyncCache(context.Background(), ttl)
+	}()
+	select {
+	case <-resyncDone:
+		t.Fatal("resync ran during the warm-up")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.Zero(t, cache.cleared)
+
+	close(releaseWarmup)
+	require.NoError(t, <-warmupDone)
+	require.NoError(t, <-resyncDone)
+	require.Equal(t, 1, cache.cleared)
+	require.Equal(t, usecase.WarmupDone, order.WarmupStatus().State)
+}
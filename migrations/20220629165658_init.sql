@@ -62,8 +62,8 @@ CREATE TABLE IF NOT EXISTS items(
 
 -- +goose Down
 -- +goose StatementBegin
+DROP TABLE IF EXISTS items;
 DROP TABLE IF EXISTS orders;
 DROP TABLE IF EXISTS deliveries;
 DROP TABLE IF EXISTS payments;
-DROP TABLE IF EXISTS items;
 -- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE bigint,
+    ALTER COLUMN delivery_cost TYPE bigint,
+    ALTER COLUMN goods_total TYPE bigint,
+    ALTER COLUMN custom_fee TYPE bigint,
+    ADD COLUMN currency_exponent smallint NOT NULL DEFAULT 2;
+
+UPDATE payments SET currency_exponent = 0
+WHERE currency IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'VND', 'VUV', 'XAF', 'XOF', 'XPF');
+
+UPDATE payments SET currency_exponent = 3
+WHERE currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND');
+
+ALTER TABLE payments ALTER COLUMN currency_exponent DROP DEFAULT;
+
+ALTER TABLE items
+    ALTER COLUMN price TYPE bigint,
+    ALTER COLUMN total_price TYPE bigint;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE items
+    ALTER COLUMN price TYPE int,
+    ALTER COLUMN total_price TYPE int;
+
+ALTER TABLE payments
+    DROP COLUMN currency_exponent,
+    ALTER COLUMN amount TYPE int,
+    ALTER COLUMN delivery_cost TYPE int,
+    ALTER COLUMN goods_total TYPE int,
+    ALTER COLUMN custom_fee TYPE int;
+-- +goose StatementEnd
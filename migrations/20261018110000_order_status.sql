@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders
+    ADD COLUMN status varchar NOT NULL DEFAULT 'created'
+    CHECK (status IN ('created', 'paid', 'assembled', 'shipped', 'delivered', 'cancelled', 'returned'));
+
+CREATE TABLE IF NOT EXISTS order_status_history(
+    id int PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    order_uid varchar NOT NULL,
+    status varchar NOT NULL,
+    reason varchar NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+
+    FOREIGN KEY (order_uid) REFERENCES orders(order_uid)
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_uid_idx ON order_status_history(order_uid, created_at);
+
+INSERT INTO order_status_history(order_uid, status, reason, created_at)
+SELECT order_uid, 'created', 'order created', date_created FROM orders;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_status_history;
+
+ALTER TABLE orders DROP COLUMN status;
+-- +goose StatementEnd
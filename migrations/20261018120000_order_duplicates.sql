@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS order_duplicates(
+    id int PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    order_uid varchar NOT NULL,
+    payload jsonb NOT NULL,
+    received_at timestamptz NOT NULL DEFAULT now(),
+
+    FOREIGN KEY (order_uid) REFERENCES orders(order_uid)
+);
+
+CREATE INDEX IF NOT EXISTS order_duplicates_order_uid_idx ON order_duplicates(order_uid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_duplicates;
+-- +goose StatementEnd
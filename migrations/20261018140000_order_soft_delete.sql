@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE orders
+    ADD COLUMN cancelled_at timestamptz,
+    ADD COLUMN deleted_at timestamptz;
+
+UPDATE orders SET cancelled_at = history.created_at
+FROM (
+    SELECT order_uid, max(created_at) AS created_at
+    FROM order_status_history
+    WHERE status = 'cancelled'
+    GROUP BY order_uid
+) AS history
+WHERE orders.order_uid = history.order_uid;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders
+    DROP COLUMN deleted_at,
+    DROP COLUMN cancelled_at;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS orders_date_created_order_uid_idx ON orders(date_created DESC, order_uid DESC)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS items_track_number_idx ON items(track_number);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS items_track_number_idx;
+DROP INDEX IF EXISTS orders_date_created_order_uid_idx;
+-- +goose StatementEnd
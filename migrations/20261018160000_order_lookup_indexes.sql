@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS orders_customer_id_idx ON orders(customer_id, date_created DESC)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS items_rid_idx ON items(rid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS items_rid_idx;
+DROP INDEX IF EXISTS orders_customer_id_idx;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS orders_customer_id_listing_idx ON orders(customer_id, date_created DESC, order_uid DESC)
+WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS orders_delivery_service_idx ON orders(delivery_service, date_created DESC, order_uid DESC)
+WHERE deleted_at IS NULL;
+
+-- entry and locale have few distinct values, filters on them are served by orders_date_created_order_uid_idx
+CREATE INDEX IF NOT EXISTS payments_provider_idx ON payments(provider);
+
+CREATE INDEX IF NOT EXISTS payments_currency_amount_idx ON payments(currency, amount);
+
+CREATE INDEX IF NOT EXISTS items_brand_idx ON items(brand);
+
+DROP INDEX IF EXISTS orders_customer_id_idx;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS orders_customer_id_idx ON orders(customer_id, date_created DESC)
+WHERE deleted_at IS NULL;
+
+DROP INDEX IF EXISTS items_brand_idx;
+DROP INDEX IF EXISTS payments_currency_amount_idx;
+DROP INDEX IF EXISTS payments_provider_idx;
+DROP INDEX IF EXISTS orders_delivery_service_idx;
+DROP INDEX IF EXISTS orders_customer_id_listing_idx;
+-- +goose StatementEnd
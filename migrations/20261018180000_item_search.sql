@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the column is generated, so it is filled for the existing rows by the migration and kept up to date by postgres
+ALTER TABLE items ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', brand), 'A') || setweight(to_tsvector('simple', name), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS items_search_vector_idx ON items USING gin(search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS items_search_vector_idx;
+
+ALTER TABLE items DROP COLUMN IF EXISTS search_vector;
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+-- payments no order refers to can not be keyed by an order, they are kept aside for a manual review
+CREATE TABLE IF NOT EXISTS payments_orphaned (LIKE payments INCLUDING DEFAULTS);
+
+INSERT INTO payments_orphaned
+SELECT * FROM payments
+WHERE NOT EXISTS (SELECT 1 FROM orders WHERE orders.order_uid = payments.transaction);
+
+DELETE FROM payments WHERE transaction IN (SELECT transaction FROM payments_orphaned);
+
+-- payments are keyed by the order instead of the order referencing its payment by the transaction id
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS order_uid varchar;
+
+UPDATE payments SET order_uid = orders.order_uid
+FROM orders WHERE orders.order_uid = payments.transaction;
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_order_uid_fkey;
+
+ALTER TABLE payments
+    DROP CONSTRAINT payments_pkey,
+    ALTER COLUMN order_uid SET NOT NULL,
+    ADD PRIMARY KEY (order_uid),
+    ADD CONSTRAINT payments_transaction_key UNIQUE (transaction),
+    ADD CONSTRAINT payments_order_uid_fkey FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE;
+
+-- items keep their track number, but belong to the order through order_uid
+ALTER TABLE items ADD COLUMN IF NOT EXISTS order_uid varchar;
+
+UPDATE items SET order_uid = orders.order_uid
+FROM orders WHERE orders.track_number = items.track_number;
+
+ALTER TABLE items
+    DROP CONSTRAINT IF EXISTS items_track_number_fkey,
+    ALTER COLUMN order_uid SET NOT NULL,
+    ADD CONSTRAINT items_order_uid_fkey FOREIGN KEY (order_uid) REFERENCES orders(order_uid) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS items_track_number_idx;
+
+CREATE INDEX IF NOT EXISTS items_order_uid_idx ON items(order_uid, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS items_order_uid_idx;
+
+CREATE INDEX IF NOT EXISTS items_track_number_idx ON items(track_number);
+
+ALTER TABLE items
+    DROP CONSTRAINT IF EXISTS items_order_uid_fkey,
+    DROP COLUMN IF EXISTS order_uid,
+    ADD CONSTRAINT items_track_number_fkey FOREIGN KEY (track_number) REFERENCES orders(track_number);
+
+-- fails if a transaction id differs from its order uid, such orders can not be stored in the former schema
+ALTER TABLE payments
+    DROP CONSTRAINT IF EXISTS payments_order_uid_fkey,
+    DROP CONSTRAINT IF EXISTS payments_transaction_key,
+    DROP CONSTRAINT payments_pkey,
+    ADD PRIMARY KEY (transaction);
+
+ALTER TABLE orders ADD CONSTRAINT orders_order_uid_fkey FOREIGN KEY (order_uid) REFERENCES payments(transaction);
+
+ALTER TABLE payments DROP COLUMN IF EXISTS order_uid;
+
+INSERT INTO payments SELECT * FROM payments_orphaned;
+
+DROP TABLE IF EXISTS payments_orphaned;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- changed_at is set by the service on every write to the order, including status changes and soft deletes,
+-- unlike date_created supplied by the producer. Existing orders get the migration time, so a snapshot taken
+-- before it reconciles all of them.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS changed_at timestamptz NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS orders_changed_at_idx ON orders(changed_at)
+WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS orders_changed_at_idx;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS changed_at;
+-- +goose StatementEnd
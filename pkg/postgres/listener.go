@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/maypok86/wb-l0/pkg/logger"
+)
+
+const defaultReconnectInterval = time.Second
+
+type listenerConfig struct {
+	reconnectInterval time.Duration
+	onReconnect       func(context.Context)
+}
+
+type ListenerOption func(*listenerConfig)
+
+// ReconnectInterval sets the pause between reconnection attempts.
+func ReconnectInterval(interval time.Duration) ListenerOption {
+	return func(c *listenerConfig) {
+		if interval > 0 {
+			c.reconnectInterval = interval
+		}
+	}
+}
+
+// OnReconnect sets the callback called once the listener is subscribed after a lost connection or a failed attempt
+// to subscribe. Notifications sent while the listener was not subscribed are lost, so the callback should resync the state.
+func OnReconnect(onReconnect func(context.Context)) ListenerOption {
+	return func(c *listenerConfig) {
+		c.onReconnect = onReconnect
+	}
+}
+
+// Listener receives the notifications sent to a channel over a dedicated connection,
+// the pool connections are not suitable for LISTEN.
+type Listener struct {
+	dsn               string
+	channel           string
+	onNotification    func(payload string)
+	reconnectInterval time.Duration
+	onReconnect       func(context.Context)
+	// listen is the single connection attempt, replaced in tests
+	listen func(ctx context.Context, subscribed func()) error
+}
+
+func NewListener(
+	connectionConfig ConnectionConfig,
+	channel string,
+	onNotification func(payload string),
+	opts ...ListenerOption,
+) *Listener {
+	cfg := &listenerConfig{reconnectInterval: defaultReconnectInterval}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	l := &Listener{
+		dsn:               connectionConfig.getDSN(),
+		channel:           channel,
+		onNotification:    onNotification,
+		reconnectInterval: cfg.reconnectInterval,
+		onReconnect:       cfg.onReconnect,
+	}
+	l.listen = l.listenOnce
+	return l
+}
+
+// Listen passes the notifications to the handler until ctx is done, reconnecting whenever the connection is lost.
+func (l *Listener) Listen(ctx context.Context) {
+	// set once an attempt fails, even the first subscription may come after changes the caller relies on
+	missed := false
+	for {
+		err := l.listen(ctx, func() {
+			if missed && l.onReconnect != nil {
+				l.onReconnect(ctx)
+			}
+			missed = false
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warnf("Postgres listener on channel %s lost the connection, reconnecting: %v", l.channel, err)
+		missed = true
+
+		select {
+		case <-time.After(l.reconnectInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// listenOnce subscribes to the channel, calls subscribed and waits for notifications until the connection fails.
+func (l *Listener) listenOnce(ctx context.Context, subscribed func()) error {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return fmt.Errorf("can not connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return fmt.Errorf("can not listen: %w", err)
+	}
+	subscribed()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return fmt.Errorf("can not wait for notification: %w", err)
+		}
+		l.onNotification(notification.Payload)
+	}
+}
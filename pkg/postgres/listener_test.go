@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/maypok86/wb-l0/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("fatal")
+	os.Exit(m.Run())
+}
+
+// attempt scripts a connection attempt: whether it subscribes and the error it fails with afterwards.
+type attempt struct {
+	subscribes bool
+	err        error
+}
+
+func TestListener_Listen(t *testing.T) {
+	errLost := errors.New("connection lost")
+
+	tests := []struct {
+		name       string
+		attempts   []attempt
+		reconnects int
+	}{
+		{
+			name:       "subscribed at once",
+			attempts:   nil,
+			reconnects: 0,
+		},
+		{
+			name:       "connection lost",
+			attempts:   []attempt{{subscribes: true, err: errLost}},
+			reconnects: 1,
+		},
+		{
+			name:       "first attempt failed",
+			attempts:   []attempt{{subscribes: false, err: errLost}},
+			reconnects: 1,
+		},
+		{
+			name: "several failed attempts",
+			attempts: []attempt{
+				{subscribes: true, err: errLost},
+				{subscribes: false, err: errLost},
+				{subscribes: false, err: errLost},
+			},
+			reconnects: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			reconnects := 0
+			l := NewListener(ConnectionConfig{}, "channel", func(string) {},
+				ReconnectInterval(time.Millisecond),
+				OnReconnect(func(context.Context) {
+					reconnects++
+				}),
+			)
+
+			// the attempt after the scripted ones subscribes and holds until the listener is stopped
+			attempts := tt.attempts
+			l.listen = func(ctx context.Context, subscribed func()) error {
+				if len(attempts) == 0 {
+					subscribed()
+					cancel()
+					<-ctx.Done()
+					return nil
+				}
+				current := attempts[0]
+				attempts = attempts[1:]
+				if current.subscribes {
+					subscribed()
+				}
+				return current.err
+			}
+
+			l.Listen(ctx)
+			require.Empty(t, attempts)
+			require.Equal(t, tt.reconnects, reconnects)
+		})
+	}
+}
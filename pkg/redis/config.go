@@ -0,0 +1,17 @@
+package redis
+
+type Config struct {
+	Host     string
+	Port     string
+	Password string
+	DB       int
+}
+
+func NewConfig(host string, port string, password string, db int) Config {
+	return Config{
+		Host:     host,
+		Port:     port,
+		Password: password,
+		DB:       db,
+	}
+}
@@ -0,0 +1,25 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// New connects to redis and checks the connection with a ping.
+func New(ctx context.Context, config Config) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     net.JoinHostPort(config.Host, config.Port),
+		Password: config.Password,
+		DB:       config.DB,
+	})
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("can not connect to redis: %w", err)
+	}
+
+	return client, nil
+}